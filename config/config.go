@@ -1,15 +1,56 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // Config represents the configuration for the knowledge storage.
 type Config struct {
 	DataPath string
+
+	// ElasticsearchURL, when set, switches the search engine to the
+	// Elasticsearch/OpenSearch-backed implementation instead of the local
+	// in-process BM25 index. Read from KNOWLEDGE_MCP_ES_URL.
+	ElasticsearchURL string
+
+	// ElasticsearchIndex is the index/alias name used for atom documents.
+	// Defaults to "knowledge-atoms".
+	ElasticsearchIndex string
+
+	// PreferredFormat is the codec extension (e.g. "yaml", "json", or any
+	// extension registered via storage.RegisterCodec) that
+	// storage.FilesystemStorage.Save encodes new atoms as. Defaults to
+	// "yaml". Reading an atom never depends on this - FilesystemStorage.Load
+	// tries every registered codec regardless. The GCS and S3 backends
+	// ignore it and always write and read YAML; the in-memory backend keeps
+	// atoms unencoded.
+	PreferredFormat string
+
+	// IDScheme selects how brand-new atoms are assigned an ID. Defaults to
+	// IDSchemeSequential. Read from KNOWLEDGE_MCP_ID_SCHEME.
+	IDScheme IDScheme
 }
 
+// IDScheme selects how a new atom is assigned an ID.
+type IDScheme string
+
+const (
+	// IDSchemeSequential assigns IDs from an incrementing counter, e.g.
+	// "K-000001". This is the default.
+	IDSchemeSequential IDScheme = "sequential"
+
+	// IDSchemeContentHash assigns IDs derived from a hash of the atom's
+	// content, e.g. "K-sha256-a1b2c3d4e5f6" (see models.NewContentHashID).
+	// Atoms with identical content always get the same ID, which
+	// deduplicates knowledge captured independently across repos and gives
+	// Git-friendly, stable IDs that don't depend on creation order.
+	IDSchemeContentHash IDScheme = "content-hash"
+)
+
 // New creates a new configuration.
 func New(dataPath string) *Config {
 	if dataPath == "" {
@@ -27,28 +68,98 @@ func New(dataPath string) *Config {
 		}
 	}
 
+	esIndex := os.Getenv("KNOWLEDGE_MCP_ES_INDEX")
+	if esIndex == "" {
+		esIndex = "knowledge-atoms"
+	}
+
+	preferredFormat := os.Getenv("KNOWLEDGE_MCP_FORMAT")
+	if preferredFormat == "" {
+		preferredFormat = "yaml"
+	}
+
+	idScheme := IDScheme(os.Getenv("KNOWLEDGE_MCP_ID_SCHEME"))
+	if idScheme == "" {
+		idScheme = IDSchemeSequential
+	}
+
 	return &Config{
-		DataPath: dataPath,
+		DataPath:           dataPath,
+		ElasticsearchURL:   os.Getenv("KNOWLEDGE_MCP_ES_URL"),
+		ElasticsearchIndex: esIndex,
+		PreferredFormat:    preferredFormat,
+		IDScheme:           idScheme,
 	}
 }
 
-// IndexPath returns the path to the index.yaml file.
+// IndexPath returns the path to the index.yaml file. For object-store
+// backends this is the local cache of the bucket's shared index, not the
+// bucket object itself - see storage.RemoteIndexSource.
 func (c *Config) IndexPath() string {
+	if c.IsRemote() {
+		return filepath.Join(c.LocalCacheDir(), "index.yaml")
+	}
 	return filepath.Join(c.DataPath, "index.yaml")
 }
 
 // IndexPathJSON returns the path to the legacy index.json file.
 func (c *Config) IndexPathJSON() string {
+	if c.IsRemote() {
+		return filepath.Join(c.LocalCacheDir(), "index.json")
+	}
 	return filepath.Join(c.DataPath, "index.json")
 }
 
-// AtomsPath returns the path to the atoms directory.
+// IsRemote reports whether DataPath points at an object store (gs:// or
+// s3://) or the in-memory backend (mem://) rather than a local directory.
+func (c *Config) IsRemote() bool {
+	return strings.HasPrefix(c.DataPath, "gs://") || strings.HasPrefix(c.DataPath, "s3://") || strings.HasPrefix(c.DataPath, "mem://")
+}
+
+// AtomsPath returns the path to the atoms directory. It is a no-op ("")
+// for object-store and in-memory backends, which lay atoms out under their
+// own bucket prefix or in-process map rather than a local directory.
 func (c *Config) AtomsPath() string {
+	if c.IsRemote() {
+		return ""
+	}
 	return filepath.Join(c.DataPath, "atoms")
 }
 
-// EnsureDirs ensures the storage directories exist.
+// LocalCacheDir returns the local directory used to cache a remote
+// object-store backend's index.yaml between polls. Local filesystem
+// backends don't use this - they read/write index.yaml directly under
+// DataPath.
+func (c *Config) LocalCacheDir() string {
+	sum := sha256.Sum256([]byte(c.DataPath))
+	return filepath.Join(os.TempDir(), "knowledge-mcp-cache", hex.EncodeToString(sum[:8]))
+}
+
+// FullTextIndexPath returns the path to the persisted full-text (inverted)
+// search index. Like IndexPath, this lives under LocalCacheDir for
+// object-store backends since it's a local derivative of the atoms, not
+// something published to the bucket.
+func (c *Config) FullTextIndexPath() string {
+	if c.IsRemote() {
+		return filepath.Join(c.LocalCacheDir(), "fulltext_index.json")
+	}
+	return filepath.Join(c.DataPath, "fulltext_index.json")
+}
+
+// ArchivesPath returns the path to the directory holding point-in-time
+// archive snapshots.
+func (c *Config) ArchivesPath() string {
+	return filepath.Join(c.DataPath, "archives")
+}
+
+// EnsureDirs ensures the storage directories exist. For object-store
+// backends AtomsPath is a no-op, so this only ensures LocalCacheDir exists
+// for the local index/full-text cache; it never creates a local atoms
+// directory.
 func (c *Config) EnsureDirs() error {
+	if c.IsRemote() {
+		return os.MkdirAll(c.LocalCacheDir(), 0755)
+	}
 	return os.MkdirAll(c.AtomsPath(), 0755)
 }
 
@@ -0,0 +1,470 @@
+// Package graph maintains an in-memory view of the knowledge base's link
+// graph - Atom.Links (depends_on, see_also, contradicts) plus a synthetic
+// "supersedes" edge for every Atom.Supersedes entry - kept incrementally
+// consistent with storage via IndexManager.OnChange instead of being
+// rescanned from disk on every query.
+package graph
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sephriot/knowledge-mcp/models"
+	"github.com/sephriot/knowledge-mcp/storage"
+)
+
+// relSupersedes is the synthetic relationship used for Atom.Supersedes
+// entries, alongside the real Link.Rel values.
+const relSupersedes = "supersedes"
+
+// findContradictionsDefaultHops is used when FindContradictions is called
+// with maxHops <= 0.
+const findContradictionsDefaultHops = 3
+
+// Edge is one directed relationship between two atoms.
+type Edge struct {
+	From string
+	To   string
+	Rel  string
+}
+
+// other returns the end of e that isn't node - e.g. for an edge discovered
+// while standing at node, the neighbor it leads to.
+func (e Edge) other(node string) string {
+	if e.From == node {
+		return e.To
+	}
+	return e.From
+}
+
+// NeighborNode is one atom visited by Neighbors' traversal.
+type NeighborNode struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Type  string `json:"type"`
+	Depth int    `json:"depth"`
+}
+
+// PathStep is one atom along the path returned by ShortestPath. Rel is the
+// relationship of the edge leading into this atom from the previous step,
+// and is empty for the first step (the path's starting atom).
+type PathStep struct {
+	ID  string `json:"id"`
+	Rel string `json:"rel,omitempty"`
+}
+
+// Cycle is one depends_on cycle found by DetectCycles, listing atom IDs in
+// cycle order - the last atom depends_on's back to the first.
+type Cycle []string
+
+// Contradiction is one atom reached by FindContradictions: AtomID is linked
+// to the query atom via a "contradicts" edge, found either directly or after
+// following "see_also" chains Hops deep.
+type Contradiction struct {
+	AtomID string `json:"atom_id"`
+	Hops   int    `json:"hops"`
+}
+
+// Graph is an in-memory, incrementally-maintained view of the knowledge
+// base's link graph, built from every atom's Links and Supersedes.
+type Graph struct {
+	atomStorage storage.AtomStorage
+
+	mu    sync.RWMutex
+	nodes map[string]*models.Atom
+	out   map[string][]Edge
+	in    map[string][]Edge
+}
+
+// New creates an empty Graph backed by atomStorage. Call Attach to populate
+// it from the atoms already in storage and keep it current as they change.
+func New(atomStorage storage.AtomStorage) *Graph {
+	return &Graph{
+		atomStorage: atomStorage,
+		nodes:       make(map[string]*models.Atom),
+		out:         make(map[string][]Edge),
+		in:          make(map[string][]Edge),
+	}
+}
+
+// Attach does a full load of g from atomStorage and registers it on
+// indexManager so every later AddOrUpdate/Remove (direct or via WithTx)
+// keeps g consistent without rescanning the whole atom store.
+func (g *Graph) Attach(indexManager *storage.IndexManager) error {
+	if err := g.Reload(); err != nil {
+		return err
+	}
+	indexManager.OnChange(g.onChange)
+	return nil
+}
+
+// Reload discards g's current state and rebuilds it from every atom in
+// atomStorage.
+func (g *Graph) Reload() error {
+	ids, err := g.atomStorage.ListAllIDs()
+	if err != nil {
+		return err
+	}
+
+	nodes := make(map[string]*models.Atom, len(ids))
+	out := make(map[string][]Edge)
+	in := make(map[string][]Edge)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.nodes, g.out, g.in = nodes, out, in
+	for _, id := range ids {
+		atom, err := g.atomStorage.Load(id)
+		if err != nil || atom == nil {
+			continue
+		}
+		g.indexAtomLocked(atom)
+	}
+	return nil
+}
+
+// onChange is the IndexManager.OnChange hook: it re-derives atomID's node
+// and edges from storage (or drops them if the atom no longer exists), then
+// warns on stderr if the refreshed atom introduced a depends_on cycle or
+// links to a deprecated/superseded atom.
+func (g *Graph) onChange(atomID string) {
+	atom, err := g.atomStorage.Load(atomID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: graph failed to refresh atom %s: %v\n", atomID, err)
+		return
+	}
+
+	g.mu.Lock()
+	g.removeNodeLocked(atomID)
+	if atom != nil {
+		g.indexAtomLocked(atom)
+	}
+	g.mu.Unlock()
+
+	if atom == nil {
+		return
+	}
+	g.warnOnIntroducedCycle(atom)
+	g.warnOnStaleLinks(atom)
+}
+
+// indexAtomLocked adds atom's node and outgoing edges to the graph. Caller
+// must hold the write lock.
+func (g *Graph) indexAtomLocked(atom *models.Atom) {
+	g.nodes[atom.ID] = atom
+	for _, link := range atom.Links {
+		g.addEdgeLocked(atom.ID, link.ID, string(link.Rel))
+	}
+	for _, supersededID := range atom.Supersedes {
+		g.addEdgeLocked(atom.ID, supersededID, relSupersedes)
+	}
+}
+
+func (g *Graph) addEdgeLocked(from, to, rel string) {
+	e := Edge{From: from, To: to, Rel: rel}
+	g.out[from] = append(g.out[from], e)
+	g.in[to] = append(g.in[to], e)
+}
+
+// removeNodeLocked drops atomID's node along with every edge touching it,
+// from both the outgoing and incoming adjacency maps. Caller must hold the
+// write lock.
+func (g *Graph) removeNodeLocked(atomID string) {
+	delete(g.nodes, atomID)
+	for _, e := range g.out[atomID] {
+		g.in[e.To] = removeEdge(g.in[e.To], e)
+	}
+	delete(g.out, atomID)
+	for _, e := range g.in[atomID] {
+		g.out[e.From] = removeEdge(g.out[e.From], e)
+	}
+	delete(g.in, atomID)
+}
+
+func removeEdge(edges []Edge, target Edge) []Edge {
+	kept := edges[:0]
+	for _, e := range edges {
+		if e != target {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// neighborsOfLocked returns every edge touching id, optionally restricted to
+// a single relationship. Caller must hold at least the read lock.
+func (g *Graph) neighborsOfLocked(id, rel string) []Edge {
+	var edges []Edge
+	for _, e := range g.out[id] {
+		if rel == "" || e.Rel == rel {
+			edges = append(edges, e)
+		}
+	}
+	for _, e := range g.in[id] {
+		if rel == "" || e.Rel == rel {
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+// Neighbors returns every atom reachable from id within depth hops (default
+// 2), optionally restricted to a single relationship. Traversal follows
+// edges in either direction, same as tools.AtomTools.RelatedAtoms.
+func (g *Graph) Neighbors(id string, rel string, depth int) ([]NeighborNode, error) {
+	if depth <= 0 {
+		depth = 2
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if _, ok := g.nodes[id]; !ok {
+		return nil, fmt.Errorf("atom %s not found", id)
+	}
+
+	visited := map[string]int{id: 0}
+	queue := []string{id}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		d := visited[current]
+		if d >= depth {
+			continue
+		}
+
+		for _, e := range g.neighborsOfLocked(current, rel) {
+			next := e.other(current)
+			if _, seen := visited[next]; seen {
+				continue
+			}
+			visited[next] = d + 1
+			queue = append(queue, next)
+		}
+	}
+
+	result := make([]NeighborNode, 0, len(visited)-1)
+	for atomID, d := range visited {
+		if atomID == id {
+			continue
+		}
+		atom, ok := g.nodes[atomID]
+		if !ok {
+			continue
+		}
+		result = append(result, NeighborNode{ID: atom.ID, Title: atom.Title, Type: string(atom.Type), Depth: d})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Depth != result[j].Depth {
+			return result[i].Depth < result[j].Depth
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+// ShortestPath returns the shortest path from from to to as a sequence of
+// steps, following edges in either direction. It returns (nil, nil) if the
+// atoms are disconnected.
+func (g *Graph) ShortestPath(from, to string) ([]PathStep, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if _, ok := g.nodes[from]; !ok {
+		return nil, fmt.Errorf("atom %s not found", from)
+	}
+	if _, ok := g.nodes[to]; !ok {
+		return nil, fmt.Errorf("atom %s not found", to)
+	}
+	if from == to {
+		return []PathStep{{ID: from}}, nil
+	}
+
+	type parent struct {
+		id  string
+		rel string
+	}
+	prev := map[string]parent{from: {}}
+	queue := []string{from}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			break
+		}
+		for _, e := range g.neighborsOfLocked(current, "") {
+			next := e.other(current)
+			if _, seen := prev[next]; seen {
+				continue
+			}
+			prev[next] = parent{id: current, rel: e.Rel}
+			queue = append(queue, next)
+		}
+	}
+
+	if _, reached := prev[to]; !reached {
+		return nil, nil
+	}
+
+	var steps []PathStep
+	for at := to; ; {
+		p := prev[at]
+		steps = append([]PathStep{{ID: at, Rel: p.rel}}, steps...)
+		if p.id == "" {
+			break
+		}
+		at = p.id
+	}
+	return steps, nil
+}
+
+// DetectCycles reports cycles among depends_on edges only - the one
+// relationship where a cycle is a modeling error rather than a legitimate
+// graph shape (unlike see_also or contradicts, which are naturally
+// symmetric). It records one cycle per back edge found by a depth-first
+// search, so a non-empty result means the depends_on graph has a cycle, but
+// not every distinct cycle is listed.
+func (g *Graph) DetectCycles() []Cycle {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	const color0, color1, color2 = 0, 1, 2 // white, gray (on stack), black
+	color := make(map[string]int, len(g.nodes))
+	var stack []string
+	var cycles []Cycle
+
+	var visit func(node string)
+	visit = func(node string) {
+		color[node] = color1
+		stack = append(stack, node)
+
+		for _, e := range g.out[node] {
+			if e.Rel != string(models.LinkRelDependsOn) {
+				continue
+			}
+			switch color[e.To] {
+			case color0:
+				visit(e.To)
+			case color1:
+				for i, id := range stack {
+					if id == e.To {
+						cycles = append(cycles, append(Cycle{}, stack[i:]...))
+						break
+					}
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		color[node] = color2
+	}
+
+	ids := make([]string, 0, len(g.nodes))
+	for id := range g.nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if color[id] == color0 {
+			visit(id)
+		}
+	}
+	return cycles
+}
+
+// FindContradictions transitively surfaces every atom linked to id via
+// "contradicts", either directly or reachable by following "see_also"
+// chains up to maxHops hops (default findContradictionsDefaultHops) from
+// id. Hops reports how many see_also hops away the contradiction was found.
+func (g *Graph) FindContradictions(id string, maxHops int) ([]Contradiction, error) {
+	if maxHops <= 0 {
+		maxHops = findContradictionsDefaultHops
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if _, ok := g.nodes[id]; !ok {
+		return nil, fmt.Errorf("atom %s not found", id)
+	}
+
+	seenAt := map[string]int{id: 0}
+	queue := []string{id}
+	foundContradiction := make(map[string]bool)
+	var results []Contradiction
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		hops := seenAt[current]
+
+		for _, e := range g.neighborsOfLocked(current, string(models.LinkRelContradicts)) {
+			target := e.other(current)
+			if foundContradiction[target] {
+				continue
+			}
+			foundContradiction[target] = true
+			results = append(results, Contradiction{AtomID: target, Hops: hops})
+		}
+
+		if hops >= maxHops {
+			continue
+		}
+		for _, e := range g.neighborsOfLocked(current, string(models.LinkRelSeeAlso)) {
+			next := e.other(current)
+			if _, seen := seenAt[next]; seen {
+				continue
+			}
+			seenAt[next] = hops + 1
+			queue = append(queue, next)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Hops != results[j].Hops {
+			return results[i].Hops < results[j].Hops
+		}
+		return results[i].AtomID < results[j].AtomID
+	})
+	return results, nil
+}
+
+// warnOnIntroducedCycle logs to stderr if atom participates in a depends_on
+// cycle, matching the Warning-prefixed stderr convention used elsewhere in
+// this codebase (e.g. storage.IndexManager.RebuildFromAtoms).
+func (g *Graph) warnOnIntroducedCycle(atom *models.Atom) {
+	for _, cycle := range g.DetectCycles() {
+		for _, id := range cycle {
+			if id != atom.ID {
+				continue
+			}
+			path := append(append(Cycle{}, cycle...), cycle[0])
+			fmt.Fprintf(os.Stderr, "Warning: atom %s is part of a depends_on cycle: %s\n", atom.ID, strings.Join(path, " -> "))
+			return
+		}
+	}
+}
+
+// warnOnStaleLinks logs to stderr for every link of atom that points at a
+// deprecated or superseded atom.
+func (g *Graph) warnOnStaleLinks(atom *models.Atom) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	for _, link := range atom.Links {
+		target, ok := g.nodes[link.ID]
+		if !ok {
+			continue
+		}
+		if target.Status == models.AtomStatusDeprecated {
+			fmt.Fprintf(os.Stderr, "Warning: atom %s links to deprecated atom %s\n", atom.ID, target.ID)
+		} else if target.SupersededBy != nil {
+			fmt.Fprintf(os.Stderr, "Warning: atom %s links to superseded atom %s (superseded by %s)\n", atom.ID, target.ID, *target.SupersededBy)
+		}
+	}
+}
@@ -2,24 +2,39 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
 	"github.com/sephriot/knowledge-mcp/config"
+	"github.com/sephriot/knowledge-mcp/graph"
 	"github.com/sephriot/knowledge-mcp/models"
 	"github.com/sephriot/knowledge-mcp/storage"
 	"github.com/sephriot/knowledge-mcp/tools"
 )
 
+// remoteIndexPollInterval is how often object-store-backed AtomStorage
+// implementations check the bucket for a newer index.yaml.
+const remoteIndexPollInterval = 30 * time.Second
+
 func main() {
 	dataPath := flag.String("data-path", "", "Path to knowledge storage (default: .knowledge or KNOWLEDGE_MCP_PATH env)")
+	transport := flag.String("transport", "stdio", `Transport to serve over: "stdio", "http", or "sse"`)
+	httpAddr := flag.String("http-addr", ":8080", "Address to listen on for the http/sse transports")
+	authToken := flag.String("auth-token", "", "Bearer token required to authenticate http/sse requests (default: KNOWLEDGE_MCP_AUTH_TOKEN env)")
 	flag.Parse()
 
+	if *authToken == "" {
+		*authToken = os.Getenv("KNOWLEDGE_MCP_AUTH_TOKEN")
+	}
+
 	// Configure the data path
 	cfg := config.New(*dataPath)
 	config.SetConfig(cfg)
@@ -34,13 +49,36 @@ func main() {
 
 	// Initialize shared index manager and tools
 	indexManager := storage.NewIndexManager(cfg)
-	searchEngine := tools.NewSearchEngine(cfg, indexManager)
+
+	// For object-store-backed data paths (gs://, s3://), keep the local
+	// index cache warm by polling the bucket for changes in the
+	// background, invalidating IndexManager's in-memory copy whenever the
+	// remote index's generation/ETag moves.
+	if remoteIndex, ok := storage.NewAtomStorage(cfg).(storage.RemoteIndexSource); ok {
+		remoteIndex.WatchIndex(remoteIndexPollInterval, indexManager.InvalidateCache)
+	}
+
+	searchEngine := tools.NewSearcher(cfg, indexManager)
+	if err := tools.AttachToIndex(searchEngine, indexManager, storage.NewAtomStorage(cfg)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to build search index: %v\n", err)
+	}
 	upsertHandler := tools.NewUpsertHandler(cfg, indexManager)
 	atomTools := tools.NewAtomTools(cfg, indexManager)
+	attachmentTools := tools.NewAttachmentTools(cfg, indexManager)
+
+	// The knowledge graph keeps its own in-memory copy of the link graph,
+	// refreshed incrementally via indexManager's change hooks rather than
+	// rescanned on every graph_* call.
+	kgraph := graph.New(storage.NewAtomStorage(cfg))
+	if err := kgraph.Attach(indexManager); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to build knowledge graph: %v\n", err)
+	}
+	graphTools := tools.NewGraphTools(kgraph)
 
 	// Register tools
 	registerSearchTool(s, searchEngine)
 	registerUpsertTool(s, upsertHandler)
+	registerUpsertManyTool(s, upsertHandler)
 	registerListAtomsTool(s, atomTools)
 	registerGetAtomTool(s, atomTools)
 	registerDeleteAtomTool(s, atomTools)
@@ -50,15 +88,79 @@ func main() {
 	registerRebuildIndexTool(s, atomTools)
 	registerGetSummaryTool(s, atomTools)
 	registerGetNextIDTool(s, atomTools)
+	registerAttachFileTool(s, attachmentTools)
+	registerAppendFileTool(s, attachmentTools)
+	registerGetFileTool(s, attachmentTools)
+	registerListFilesTool(s, attachmentTools)
+	registerDeleteFileTool(s, attachmentTools)
+	registerArchiveAtomsTool(s, atomTools)
+	registerRestoreArchiveTool(s, atomTools)
+	registerRelatedAtomsTool(s, atomTools)
+	registerGraphNeighborsTool(s, graphTools)
+	registerGraphShortestPathTool(s, graphTools)
+	registerGraphDetectCyclesTool(s, graphTools)
+	registerGraphFindContradictionsTool(s, graphTools)
 
 	// Start server
-	if err := server.ServeStdio(s); err != nil {
+	if err := serve(s, *transport, *httpAddr, *authToken); err != nil {
 		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func registerSearchTool(s *server.MCPServer, engine *tools.SearchEngine) {
+// serve starts s over the requested transport and blocks until it exits.
+// All tools are registered on s beforehand, so they work identically
+// regardless of which transport carries them.
+func serve(s *server.MCPServer, transport, httpAddr, authToken string) error {
+	switch transport {
+	case "stdio":
+		return server.ServeStdio(s)
+	case "http":
+		return serveHTTP(server.NewStreamableHTTPServer(s), httpAddr, authToken)
+	case "sse":
+		return serveHTTP(server.NewSSEServer(s), httpAddr, authToken)
+	default:
+		return fmt.Errorf("unknown transport %q (want stdio, http, or sse)", transport)
+	}
+}
+
+// serveHTTP mounts handler behind bearer-token auth alongside /healthz and
+// /readyz endpoints so the server can run behind a container orchestrator.
+func serveHTTP(handler http.Handler, addr, authToken string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+	mux.Handle("/", requireBearerToken(authToken, handler))
+
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+// requireBearerToken rejects requests whose Authorization header doesn't
+// match "Bearer <token>". If token is empty, auth is disabled - useful for
+// local development behind an already-trusted network boundary.
+func requireBearerToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+
+	expected := "Bearer " + token
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func registerSearchTool(s *server.MCPServer, engine tools.Searcher) {
 	s.AddTool(mcp.NewTool("search",
 		mcp.WithDescription(`Search knowledge atoms by title, tags, and content.
 
@@ -70,6 +172,7 @@ Args:
     status: Filter by status (active, draft, deprecated).
     limit: Maximum results (default 10).
     include_content: Search in atom content (summary, details) too. Slower but more thorough.
+    fuzziness: Typo tolerance - "off", "auto" (default), or a number fixing the max edit distance.
 
 Returns:
     List of matching atoms with metadata and summary.`),
@@ -80,6 +183,7 @@ Returns:
 		mcp.WithString("status", mcp.Description("Filter by status")),
 		mcp.WithNumber("limit", mcp.Description("Maximum results")),
 		mcp.WithBoolean("include_content", mcp.Description("Search in atom content too")),
+		mcp.WithString("fuzziness", mcp.Description(`Typo tolerance: "off", "auto", or a number`)),
 	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 
 		query, _ := request.Params.Arguments["query"].(string)
@@ -122,12 +226,14 @@ Returns:
 			includeContent = ic
 		}
 
+		fuzziness := getString(request.Params.Arguments, "fuzziness")
+
 		var results []tools.SearchResult
 		var err error
 		if includeContent {
-			results, err = engine.SearchContent(query, types, tags, language, status, limit)
+			results, err = engine.SearchContent(query, types, tags, language, status, limit, fuzziness)
 		} else {
-			results, err = engine.Search(query, types, tags, language, status, limit)
+			results, err = engine.Search(query, types, tags, language, status, limit, fuzziness)
 		}
 
 		if err != nil {
@@ -201,6 +307,34 @@ Returns:
 	})
 }
 
+func registerUpsertManyTool(s *server.MCPServer, handler *tools.UpsertHandler) {
+	s.AddTool(mcp.NewTool("upsert_many",
+		mcp.WithDescription(`Create or update many knowledge atoms in one transactional batch.
+
+Args:
+    atoms: List of atom inputs, each shaped like the "upsert" tool's arguments.
+    on_error: "continue" (default, commits what validated), "abort" (fails fast,
+        commits nothing), or "rollback" (validates everything, commits nothing if
+        any item failed).
+
+Returns:
+    Per-item results ({id, status, error?}) plus created/updated/errored counts.`),
+		mcp.WithArray("atoms", mcp.Required(), mcp.Description("List of atom inputs")),
+		mcp.WithString("on_error", mcp.Description("Error handling mode"), mcp.Enum("continue", "abort", "rollback")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		inputs := getUpsertInputs(request.Params.Arguments, "atoms")
+		onError := getString(request.Params.Arguments, "on_error")
+
+		result, err := handler.UpsertMany(inputs, onError)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(toJSON(result)), nil
+	})
+}
+
 func registerListAtomsTool(s *server.MCPServer, atomTools *tools.AtomTools) {
 	s.AddTool(mcp.NewTool("list_atoms",
 		mcp.WithDescription(`List knowledge atoms with filtering.
@@ -335,22 +469,27 @@ Returns:
 
 func registerExportAllTool(s *server.MCPServer, atomTools *tools.AtomTools) {
 	s.AddTool(mcp.NewTool("export_all",
-		mcp.WithDescription(`Export all knowledge as a single JSON structure.
+		mcp.WithDescription(`Export all knowledge atoms.
 
 Args:
-    format: Export format (only "json" supported).
+    format: Export format - "json" (default), "jsonl", "yaml", "markdown", or "tgz".
+    output_path: Required for "markdown" (a directory, one .md file per atom) and
+        "tgz" (the archive file path). Ignored by the other formats.
 
 Returns:
-    All atoms in a single structure.`),
-		mcp.WithString("format", mcp.Description("Export format")),
+    "json"/"jsonl"/"yaml" return their content inline; "markdown"/"tgz" write to
+    output_path and return the path.`),
+		mcp.WithString("format", mcp.Description("Export format"), mcp.Enum("json", "jsonl", "yaml", "markdown", "tgz")),
+		mcp.WithString("output_path", mcp.Description("Destination path for the markdown/tgz formats")),
 	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 
 		format := getString(request.Params.Arguments, "format")
 		if format == "" {
 			format = "json"
 		}
+		outputPath := getString(request.Params.Arguments, "output_path")
 
-		result, err := atomTools.ExportAll(format)
+		result, err := atomTools.ExportAll(format, outputPath)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
@@ -421,6 +560,344 @@ Returns:
 	})
 }
 
+func registerAttachFileTool(s *server.MCPServer, attachmentTools *tools.AttachmentTools) {
+	s.AddTool(mcp.NewTool("attach_file",
+		mcp.WithDescription(`Attach a new file to a knowledge atom.
+
+Args:
+    id: The atom ID to attach the file to.
+    name: File name.
+    mime_type: MIME type of the file (e.g. "text/plain", "application/pdf").
+    content_base64: File content, base64-encoded.
+
+Returns:
+    The created attachment's metadata.`),
+		mcp.WithString("id", mcp.Required(), mcp.Description("The atom ID to attach the file to")),
+		mcp.WithString("name", mcp.Required(), mcp.Description("File name")),
+		mcp.WithString("mime_type", mcp.Required(), mcp.Description("MIME type of the file")),
+		mcp.WithString("content_base64", mcp.Required(), mcp.Description("File content, base64-encoded")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		result, err := attachmentTools.AttachFile(
+			getString(request.Params.Arguments, "id"),
+			getString(request.Params.Arguments, "name"),
+			getString(request.Params.Arguments, "mime_type"),
+			getString(request.Params.Arguments, "content_base64"),
+		)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(toJSON(result)), nil
+	})
+}
+
+func registerAppendFileTool(s *server.MCPServer, attachmentTools *tools.AttachmentTools) {
+	s.AddTool(mcp.NewTool("append_file",
+		mcp.WithDescription(`Append bytes to an existing attachment.
+
+Args:
+    id: The atom ID owning the attachment.
+    attachment_id: The attachment ID to append to.
+    content_base64: Bytes to append, base64-encoded.
+
+Returns:
+    The updated attachment's metadata.`),
+		mcp.WithString("id", mcp.Required(), mcp.Description("The atom ID owning the attachment")),
+		mcp.WithString("attachment_id", mcp.Required(), mcp.Description("The attachment ID to append to")),
+		mcp.WithString("content_base64", mcp.Required(), mcp.Description("Bytes to append, base64-encoded")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		result, err := attachmentTools.AppendFile(
+			getString(request.Params.Arguments, "id"),
+			getString(request.Params.Arguments, "attachment_id"),
+			getString(request.Params.Arguments, "content_base64"),
+		)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(toJSON(result)), nil
+	})
+}
+
+func registerGetFileTool(s *server.MCPServer, attachmentTools *tools.AttachmentTools) {
+	s.AddTool(mcp.NewTool("get_file",
+		mcp.WithDescription(`Get an attachment's metadata and content.
+
+Args:
+    id: The atom ID owning the attachment.
+    attachment_id: The attachment ID to fetch.
+
+Returns:
+    Attachment metadata plus base64-encoded content.`),
+		mcp.WithString("id", mcp.Required(), mcp.Description("The atom ID owning the attachment")),
+		mcp.WithString("attachment_id", mcp.Required(), mcp.Description("The attachment ID to fetch")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		result, err := attachmentTools.GetFile(
+			getString(request.Params.Arguments, "id"),
+			getString(request.Params.Arguments, "attachment_id"),
+		)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(toJSON(result)), nil
+	})
+}
+
+func registerListFilesTool(s *server.MCPServer, attachmentTools *tools.AttachmentTools) {
+	s.AddTool(mcp.NewTool("list_files",
+		mcp.WithDescription(`List attachments on a knowledge atom.
+
+Args:
+    id: The atom ID.
+
+Returns:
+    List of attachment metadata.`),
+		mcp.WithString("id", mcp.Required(), mcp.Description("The atom ID")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		result, err := attachmentTools.ListFiles(getString(request.Params.Arguments, "id"))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(toJSON(result)), nil
+	})
+}
+
+func registerDeleteFileTool(s *server.MCPServer, attachmentTools *tools.AttachmentTools) {
+	s.AddTool(mcp.NewTool("delete_file",
+		mcp.WithDescription(`Permanently delete an attachment from a knowledge atom.
+
+Args:
+    id: The atom ID owning the attachment.
+    attachment_id: The attachment ID to delete.
+
+Returns:
+    Result with success status.`),
+		mcp.WithString("id", mcp.Required(), mcp.Description("The atom ID owning the attachment")),
+		mcp.WithString("attachment_id", mcp.Required(), mcp.Description("The attachment ID to delete")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		result, err := attachmentTools.DeleteFile(
+			getString(request.Params.Arguments, "id"),
+			getString(request.Params.Arguments, "attachment_id"),
+		)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(toJSON(result)), nil
+	})
+}
+
+func registerArchiveAtomsTool(s *server.MCPServer, atomTools *tools.AtomTools) {
+	s.AddTool(mcp.NewTool("archive_atoms",
+		mcp.WithDescription(`Package matching atoms into a point-in-time archive snapshot.
+
+Args:
+    name: Name for the archive (the file becomes "<name>-<timestamp>.tgz").
+    types: Filter by types (optional).
+    tags: Filter by tags (optional).
+    status: Filter by status (optional).
+
+Returns:
+    The archive path and number of atoms packaged.`),
+		mcp.WithString("name", mcp.Required(), mcp.Description("Name for the archive")),
+		mcp.WithArray("types", mcp.Description("Filter by types")),
+		mcp.WithArray("tags", mcp.Description("Filter by tags")),
+		mcp.WithString("status", mcp.Description("Filter by status")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		name := getString(request.Params.Arguments, "name")
+		types := getStringArray(request.Params.Arguments, "types")
+		tags := getStringArray(request.Params.Arguments, "tags")
+
+		var status *string
+		if st := getString(request.Params.Arguments, "status"); st != "" {
+			status = &st
+		}
+
+		result, err := atomTools.ArchiveAtoms(name, types, tags, status)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(toJSON(result)), nil
+	})
+}
+
+func registerRestoreArchiveTool(s *server.MCPServer, atomTools *tools.AtomTools) {
+	s.AddTool(mcp.NewTool("restore_archive",
+		mcp.WithDescription(`Restore atoms from an archive snapshot created by archive_atoms.
+
+Args:
+    path: Path to the archive .tgz file.
+    mode: How to handle ID conflicts - "skip_existing" (default), "overwrite", or
+        "rename" (allocates fresh IDs and rewrites internal links).
+
+Returns:
+    Counts of restored, skipped, and corrupted atoms, and the new total.`),
+		mcp.WithString("path", mcp.Required(), mcp.Description("Path to the archive .tgz file")),
+		mcp.WithString("mode", mcp.Description("Conflict handling mode"), mcp.Enum("skip_existing", "overwrite", "rename")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		path := getString(request.Params.Arguments, "path")
+		mode := getString(request.Params.Arguments, "mode")
+
+		result, err := atomTools.RestoreArchive(path, mode)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(toJSON(result)), nil
+	})
+}
+
+func registerRelatedAtomsTool(s *server.MCPServer, atomTools *tools.AtomTools) {
+	s.AddTool(mcp.NewTool("related_atoms",
+		mcp.WithDescription(`Walk the knowledge graph from an atom via its links.
+
+Args:
+    id: The atom ID to start from.
+    max_depth: Maximum hops to traverse (default 2).
+    rels: Restrict traversal to these link relationships (depends_on, see_also,
+        contradicts, supersedes). Defaults to all.
+
+Returns:
+    A graph with "nodes" (visited atom summaries) and "edges" ([{from, to, rel}]).`),
+		mcp.WithString("id", mcp.Required(), mcp.Description("The atom ID to start from")),
+		mcp.WithNumber("max_depth", mcp.Description("Maximum hops to traverse")),
+		mcp.WithArray("rels", mcp.Description("Restrict traversal to these relationships")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		id := getString(request.Params.Arguments, "id")
+
+		maxDepth := 0
+		if d, ok := request.Params.Arguments["max_depth"].(float64); ok {
+			maxDepth = int(d)
+		}
+
+		rels := getStringArray(request.Params.Arguments, "rels")
+
+		result, err := atomTools.RelatedAtoms(id, maxDepth, rels)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(toJSON(result)), nil
+	})
+}
+
+func registerGraphNeighborsTool(s *server.MCPServer, graphTools *tools.GraphTools) {
+	s.AddTool(mcp.NewTool("graph_neighbors",
+		mcp.WithDescription(`Find atoms near a given atom in the knowledge graph.
+
+Args:
+    id: The atom ID to start from.
+    rel: Restrict traversal to a single relationship (depends_on, see_also,
+        contradicts, supersedes). Defaults to all.
+    depth: Maximum hops to traverse (default 2).
+
+Returns:
+    A list of neighbor atoms with their hop distance from id.`),
+		mcp.WithString("id", mcp.Required(), mcp.Description("The atom ID to start from")),
+		mcp.WithString("rel", mcp.Description("Restrict traversal to this relationship")),
+		mcp.WithNumber("depth", mcp.Description("Maximum hops to traverse")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id := getString(request.Params.Arguments, "id")
+		rel := getString(request.Params.Arguments, "rel")
+
+		depth := 0
+		if d, ok := request.Params.Arguments["depth"].(float64); ok {
+			depth = int(d)
+		}
+
+		neighbors, err := graphTools.Neighbors(id, rel, depth)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(toJSON(neighbors)), nil
+	})
+}
+
+func registerGraphShortestPathTool(s *server.MCPServer, graphTools *tools.GraphTools) {
+	s.AddTool(mcp.NewTool("graph_shortest_path",
+		mcp.WithDescription(`Find the shortest path between two atoms in the knowledge graph.
+
+Args:
+    from: The starting atom ID.
+    to: The destination atom ID.
+
+Returns:
+    A list of steps [{id, rel}] from "from" to "to" (rel is the relationship
+    leading into that step), or null if no path exists.`),
+		mcp.WithString("from", mcp.Required(), mcp.Description("The starting atom ID")),
+		mcp.WithString("to", mcp.Required(), mcp.Description("The destination atom ID")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		from := getString(request.Params.Arguments, "from")
+		to := getString(request.Params.Arguments, "to")
+
+		path, err := graphTools.ShortestPath(from, to)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(toJSON(path)), nil
+	})
+}
+
+func registerGraphDetectCyclesTool(s *server.MCPServer, graphTools *tools.GraphTools) {
+	s.AddTool(mcp.NewTool("graph_detect_cycles",
+		mcp.WithDescription(`Find cycles in the knowledge graph's depends_on relationships.
+
+A depends_on cycle almost always indicates a modeling mistake (unlike
+see_also or contradicts, which are naturally symmetric).
+
+Returns:
+    A list of cycles, each a list of atom IDs (the last depends_on's back
+    to the first).`),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		cycles := graphTools.DetectCycles()
+		return mcp.NewToolResultText(toJSON(cycles)), nil
+	})
+}
+
+func registerGraphFindContradictionsTool(s *server.MCPServer, graphTools *tools.GraphTools) {
+	s.AddTool(mcp.NewTool("graph_find_contradictions",
+		mcp.WithDescription(`Find atoms that contradict a given atom, directly or transitively.
+
+Args:
+    id: The atom ID to check.
+    max_hops: How many see_also hops to follow looking for contradictions
+        (default 3).
+
+Returns:
+    A list of [{atom_id, hops}], where hops is how many see_also hops away
+    the contradicting atom was found (0 means a direct contradicts link).`),
+		mcp.WithString("id", mcp.Required(), mcp.Description("The atom ID to check")),
+		mcp.WithNumber("max_hops", mcp.Description("How many see_also hops to follow")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id := getString(request.Params.Arguments, "id")
+
+		maxHops := 0
+		if h, ok := request.Params.Arguments["max_hops"].(float64); ok {
+			maxHops = int(h)
+		}
+
+		contradictions, err := graphTools.FindContradictions(id, maxHops)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(toJSON(contradictions)), nil
+	})
+}
+
 // Helper functions
 
 func getString(args map[string]any, key string) string {
@@ -474,6 +951,44 @@ func getSources(args map[string]any, key string) []models.Source {
 	return result
 }
 
+func getUpsertInputs(args map[string]any, key string) []tools.UpsertInput {
+	arr, ok := args[key].([]any)
+	if !ok {
+		return nil
+	}
+
+	inputs := make([]tools.UpsertInput, 0, len(arr))
+	for _, v := range arr {
+		m, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		input := tools.UpsertInput{
+			Title:      getString(m, "title"),
+			Type:       getAtomType(m, "type"),
+			Status:     getAtomStatus(m, "status"),
+			Confidence: getConfidence(m, "confidence"),
+			Summary:    getString(m, "summary"),
+			Details:    getString(m, "details"),
+			Pitfalls:   getStringArray(m, "pitfalls"),
+			Tags:       getStringArray(m, "tags"),
+			Sources:    getSources(m, "sources"),
+			Links:      getLinks(m, "links"),
+		}
+
+		if id := getString(m, "id"); id != "" {
+			input.ID = &id
+		}
+		if lang := getString(m, "language"); lang != "" {
+			input.Language = &lang
+		}
+
+		inputs = append(inputs, input)
+	}
+	return inputs
+}
+
 func getLinks(args map[string]any, key string) []models.Link {
 	arr, ok := args[key].([]any)
 	if !ok {
@@ -1,7 +1,11 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 )
 
@@ -121,6 +125,18 @@ type UpdateNote struct {
 	Note string `json:"note" yaml:"note"`
 }
 
+// Attachment represents a file attached to a knowledge atom. The bytes
+// themselves live on disk under the atom's attachments directory; this
+// struct is just the metadata stored alongside the atom.
+type Attachment struct {
+	ID        string `json:"id" yaml:"id"`
+	Name      string `json:"name" yaml:"name"`
+	MimeType  string `json:"mime_type" yaml:"mime_type"`
+	Size      int64  `json:"size" yaml:"size"`
+	SHA256    string `json:"sha256" yaml:"sha256"`
+	CreatedAt string `json:"created_at" yaml:"created_at"`
+}
+
 // AtomContent represents the content of a knowledge atom.
 type AtomContent struct {
 	Summary     string       `json:"summary" yaml:"summary"`
@@ -131,50 +147,125 @@ type AtomContent struct {
 
 // Atom represents a knowledge atom - the fundamental unit of knowledge storage.
 type Atom struct {
-	ID           string      `json:"id" yaml:"id"`
-	Title        string      `json:"title" yaml:"title"`
-	Type         AtomType    `json:"type" yaml:"type"`
-	Status       AtomStatus  `json:"status" yaml:"status"`
-	Confidence   Confidence  `json:"confidence" yaml:"confidence"`
-	Content      AtomContent `json:"content" yaml:"content"`
-	Language     *string     `json:"language,omitempty" yaml:"language,omitempty"`
-	CreatedAt    string      `json:"created_at" yaml:"created_at"`
-	UpdatedAt    string      `json:"updated_at" yaml:"updated_at"`
-	Tags         []string    `json:"tags" yaml:"tags"`
-	Sources      []Source    `json:"sources" yaml:"sources"`
-	Links        []Link      `json:"links" yaml:"links"`
-	Supersedes   []string    `json:"supersedes" yaml:"supersedes"`
-	SupersededBy *string     `json:"superseded_by,omitempty" yaml:"superseded_by,omitempty"`
+	ID           string       `json:"id" yaml:"id"`
+	Title        string       `json:"title" yaml:"title"`
+	Type         AtomType     `json:"type" yaml:"type"`
+	Status       AtomStatus   `json:"status" yaml:"status"`
+	Confidence   Confidence   `json:"confidence" yaml:"confidence"`
+	Content      AtomContent  `json:"content" yaml:"content"`
+	Language     *string      `json:"language,omitempty" yaml:"language,omitempty"`
+	CreatedAt    string       `json:"created_at" yaml:"created_at"`
+	UpdatedAt    string       `json:"updated_at" yaml:"updated_at"`
+	Tags         []string     `json:"tags" yaml:"tags"`
+	Sources      []Source     `json:"sources" yaml:"sources"`
+	Links        []Link       `json:"links" yaml:"links"`
+	Supersedes   []string     `json:"supersedes" yaml:"supersedes"`
+	SupersededBy *string      `json:"superseded_by,omitempty" yaml:"superseded_by,omitempty"`
+	Attachments  []Attachment `json:"attachments" yaml:"attachments"`
 }
 
 // IndexEntry represents an entry in the index for fast lookup.
 type IndexEntry struct {
-	ID         string     `json:"id" yaml:"id"`
-	Title      string     `json:"title" yaml:"title"`
-	Type       AtomType   `json:"type" yaml:"type"`
-	Status     AtomStatus `json:"status" yaml:"status"`
-	Confidence Confidence `json:"confidence" yaml:"confidence"`
-	Language   *string    `json:"language,omitempty" yaml:"language,omitempty"`
-	Tags       []string   `json:"tags" yaml:"tags"`
-	Path       string     `json:"path" yaml:"path"`
-	UpdatedAt  string     `json:"updated_at" yaml:"updated_at"`
+	ID              string     `json:"id" yaml:"id"`
+	Title           string     `json:"title" yaml:"title"`
+	Type            AtomType   `json:"type" yaml:"type"`
+	Status          AtomStatus `json:"status" yaml:"status"`
+	Confidence      Confidence `json:"confidence" yaml:"confidence"`
+	Language        *string    `json:"language,omitempty" yaml:"language,omitempty"`
+	Tags            []string   `json:"tags" yaml:"tags"`
+	Path            string     `json:"path" yaml:"path"`
+	UpdatedAt       string     `json:"updated_at" yaml:"updated_at"`
+	AttachmentCount int        `json:"attachment_count" yaml:"attachment_count"`
+	// ContentHash is ContentHash(atom), computed at index time. It lets
+	// callers dedupe atoms across repos without loading and re-hashing every
+	// atom file. AtomStorage.Load does not read it: Load verifies a
+	// content-hash-ID atom against the hash embedded in its own ID.
+	ContentHash string `json:"content_hash,omitempty" yaml:"content_hash,omitempty"`
 }
 
 // NewIndexEntryFromAtom creates an index entry from an atom.
 func NewIndexEntryFromAtom(atom *Atom) *IndexEntry {
 	return &IndexEntry{
-		ID:         atom.ID,
-		Title:      atom.Title,
-		Type:       atom.Type,
-		Status:     atom.Status,
-		Confidence: atom.Confidence,
-		Language:   atom.Language,
-		Tags:       atom.Tags,
-		Path:       fmt.Sprintf("atoms/%s.yaml", atom.ID),
-		UpdatedAt:  atom.UpdatedAt,
+		ID:              atom.ID,
+		Title:           atom.Title,
+		Type:            atom.Type,
+		Status:          atom.Status,
+		Confidence:      atom.Confidence,
+		Language:        atom.Language,
+		Tags:            atom.Tags,
+		Path:            fmt.Sprintf("atoms/%s.yaml", atom.ID),
+		UpdatedAt:       atom.UpdatedAt,
+		AttachmentCount: len(atom.Attachments),
+		ContentHash:     ContentHash(atom),
 	}
 }
 
+// contentHashIDPrefix marks an atom ID as content-addressable, as minted by
+// NewContentHashID.
+const contentHashIDPrefix = "K-sha256-"
+
+// ContentHash computes a stable SHA-256 hash (hex-encoded) over atom's
+// meaning-bearing fields - title, type, summary, details, pitfalls, tags,
+// sources, and links - excluding its ID, timestamps, update notes, and
+// attachments. Tags, sources, and links are sorted first, so two atoms
+// capturing identical knowledge hash the same regardless of field order.
+func ContentHash(atom *Atom) string {
+	tags := append([]string(nil), atom.Tags...)
+	sort.Strings(tags)
+
+	sources := append([]Source(nil), atom.Sources...)
+	sort.Slice(sources, func(i, j int) bool {
+		return sourceKey(sources[i]) < sourceKey(sources[j])
+	})
+
+	links := append([]Link(nil), atom.Links...)
+	sort.Slice(links, func(i, j int) bool {
+		return linkKey(links[i]) < linkKey(links[j])
+	})
+
+	canonical := struct {
+		Title    string   `json:"title"`
+		Type     AtomType `json:"type"`
+		Summary  string   `json:"summary"`
+		Details  string   `json:"details"`
+		Pitfalls []string `json:"pitfalls"`
+		Tags     []string `json:"tags"`
+		Sources  []Source `json:"sources"`
+		Links    []Link   `json:"links"`
+	}{
+		Title:    atom.Title,
+		Type:     atom.Type,
+		Summary:  atom.Content.Summary,
+		Details:  atom.Content.Details,
+		Pitfalls: atom.Content.Pitfalls,
+		Tags:     tags,
+		Sources:  sources,
+		Links:    links,
+	}
+
+	// Marshal errors can't happen for this struct (no channels, funcs, or
+	// cyclic data), so the hash is deterministic given canonical's fields.
+	data, _ := json.Marshal(canonical)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func sourceKey(s Source) string { return string(s.Kind) + "\x00" + s.Ref }
+func linkKey(l Link) string     { return string(l.Rel) + "\x00" + l.ID }
+
+// NewContentHashID returns a content-addressable ID of the form
+// "K-sha256-<hex12>" for atom, derived from ContentHash. Two atoms with
+// identical title/type/content/tags/sources/links always get the same ID,
+// which naturally dedupes knowledge captured independently across repos.
+func NewContentHashID(atom *Atom) string {
+	return contentHashIDPrefix + ContentHash(atom)[:12]
+}
+
+// IsContentHashID reports whether id was minted by NewContentHashID.
+func IsContentHashID(id string) bool {
+	return len(id) > len(contentHashIDPrefix) && id[:len(contentHashIDPrefix)] == contentHashIDPrefix
+}
+
 // Index represents the index of all knowledge atoms for fast lookup.
 type Index struct {
 	Version   int           `json:"version" yaml:"version"`
@@ -191,6 +282,19 @@ func NewEmptyIndex() *Index {
 	}
 }
 
+// Clone returns a shallow copy of the index with its own Atoms slice, so a
+// caller can stage mutations (e.g. IndexManager.WithTx) without touching the
+// original until it's ready to commit them.
+func (idx *Index) Clone() *Index {
+	clone := &Index{
+		Version:   idx.Version,
+		UpdatedAt: idx.UpdatedAt,
+		Atoms:     make([]*IndexEntry, len(idx.Atoms)),
+	}
+	copy(clone.Atoms, idx.Atoms)
+	return clone
+}
+
 // FindByID finds an entry by ID.
 func (idx *Index) FindByID(atomID string) *IndexEntry {
 	for _, entry := range idx.Atoms {
@@ -226,7 +330,10 @@ func (idx *Index) Remove(atomID string) bool {
 	return false
 }
 
-// GetNextID gets the next available atom ID.
+// GetNextID gets the next available sequential atom ID by scanning every
+// entry for the highest "K-%06d" counter value. Only used under
+// config.IDSchemeSequential - under config.IDSchemeContentHash, callers mint
+// IDs directly via NewContentHashID instead, skipping this scan entirely.
 func (idx *Index) GetNextID() string {
 	if len(idx.Atoms) == 0 {
 		return "K-000001"
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sephriot/knowledge-mcp/config"
+	"github.com/sephriot/knowledge-mcp/models"
+)
+
+// WalkFunc is called once per atom during AtomStorage.Walk. Returning an
+// error stops the walk early and the error is propagated to the caller.
+type WalkFunc func(atom *models.Atom) error
+
+// AtomStorage persists and retrieves knowledge atoms. FilesystemStorage is
+// the default, local-disk implementation; GCSStorage and S3Storage let a
+// knowledge base be published to an object store from CI and consumed
+// read-only by many MCP clients.
+type AtomStorage interface {
+	// Save persists atom, returning the location it was written to.
+	Save(atom *models.Atom) (string, error)
+
+	// Load loads an atom by ID, returning (nil, nil) if it doesn't exist.
+	Load(atomID string) (*models.Atom, error)
+
+	// Delete removes an atom, returning whether it existed.
+	Delete(atomID string) (bool, error)
+
+	// Exists reports whether an atom is present in storage.
+	Exists(atomID string) bool
+
+	// ListAllIDs lists every atom ID in storage.
+	ListAllIDs() ([]string, error)
+
+	// Walk streams every atom through fn, so callers like index rebuilds
+	// don't have to list IDs and then do N round-trips to load each one.
+	Walk(fn WalkFunc) error
+}
+
+// RemoteIndexSource is implemented by AtomStorage backends that publish a
+// shared index.yaml in the object store itself, rather than leaving
+// IndexManager to own it purely on local disk. IndexManager uses it to keep
+// a local cache of the index warm and to know when to invalidate it.
+type RemoteIndexSource interface {
+	// IndexCachePath returns the local path the shared index is mirrored
+	// to between polls.
+	IndexCachePath() string
+
+	// WatchIndex polls the remote index object on the given interval,
+	// refreshing the local cache whenever the object's generation/ETag
+	// differs from the last sync, and invoking onChange when it does. It
+	// returns a stop function that ends the polling goroutine.
+	WatchIndex(interval time.Duration, onChange func()) (stop func())
+}
+
+// NewAtomStorage returns the AtomStorage implementation selected by
+// cfg.DataPath's scheme: "gs://bucket/prefix" for Google Cloud Storage,
+// "s3://bucket/prefix" for S3, "mem://" for the in-memory backend, and a
+// plain path for the local filesystem.
+func NewAtomStorage(cfg *config.Config) AtomStorage {
+	if cfg == nil {
+		cfg = config.GetConfig()
+	}
+
+	switch {
+	case strings.HasPrefix(cfg.DataPath, "gs://"):
+		return NewGCSStorage(cfg)
+	case strings.HasPrefix(cfg.DataPath, "s3://"):
+		return NewS3Storage(cfg)
+	case strings.HasPrefix(cfg.DataPath, "mem://"):
+		return NewMemoryStorage(cfg)
+	default:
+		return NewFilesystemStorage(cfg)
+	}
+}
@@ -1,149 +1,146 @@
 package storage
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"gopkg.in/yaml.v3"
-
 	"github.com/sephriot/knowledge-mcp/config"
 	"github.com/sephriot/knowledge-mcp/models"
 )
 
-// AtomStorage manages atom file storage.
-type AtomStorage struct {
+// FilesystemStorage is the default AtomStorage implementation: it stores
+// each atom as its own file under config.AtomsPath(), encoded with a
+// registered Codec (see codec.go).
+type FilesystemStorage struct {
 	config *config.Config
 }
 
-// NewAtomStorage creates a new atom storage.
-func NewAtomStorage(cfg *config.Config) *AtomStorage {
+// NewFilesystemStorage creates a new filesystem-backed atom storage.
+func NewFilesystemStorage(cfg *config.Config) *FilesystemStorage {
 	if cfg == nil {
 		cfg = config.GetConfig()
 	}
-	return &AtomStorage{config: cfg}
+	return &FilesystemStorage{config: cfg}
 }
 
-// getAtomPathYAML returns the YAML path for an atom file.
-func (s *AtomStorage) getAtomPathYAML(atomID string) string {
-	return filepath.Join(s.config.AtomsPath(), fmt.Sprintf("%s.yaml", atomID))
+// getAtomPath returns the on-disk path for atomID under codec's extension.
+func (s *FilesystemStorage) getAtomPath(atomID string, codec Codec) string {
+	return filepath.Join(s.config.AtomsPath(), fmt.Sprintf("%s.%s", atomID, codec.Extension()))
 }
 
-// getAtomPathJSON returns the legacy JSON path for an atom file.
-func (s *AtomStorage) getAtomPathJSON(atomID string) string {
-	return filepath.Join(s.config.AtomsPath(), fmt.Sprintf("%s.json", atomID))
+// saveCodec resolves the codec Save should encode new atoms with: the one
+// named by config.PreferredFormat. An unregistered format is a
+// configuration error, not silently downgraded to YAML - a user who set
+// PreferredFormat to "toml" or "cbor" expects atoms written in that format,
+// not a quietly different one.
+func (s *FilesystemStorage) saveCodec() (Codec, error) {
+	codec, ok := GetCodec(s.config.PreferredFormat)
+	if !ok {
+		return nil, fmt.Errorf("unknown preferred atom format %q (no codec registered for it)", s.config.PreferredFormat)
+	}
+	return codec, nil
 }
 
-// Save saves an atom to disk in YAML format.
-// If a legacy JSON file exists, it is deleted after successful YAML write.
-func (s *AtomStorage) Save(atom *models.Atom) (string, error) {
+// Save saves an atom to disk, encoded with the configured preferred codec.
+// Any stale copies in other registered codecs' formats (e.g. a legacy JSON
+// file) are removed after a successful write.
+func (s *FilesystemStorage) Save(atom *models.Atom) (string, error) {
 	if err := s.config.EnsureDirs(); err != nil {
 		return "", fmt.Errorf("failed to create directories: %w", err)
 	}
 
-	yamlPath := s.getAtomPathYAML(atom.ID)
-	jsonPath := s.getAtomPathJSON(atom.ID)
+	codec, err := s.saveCodec()
+	if err != nil {
+		return "", err
+	}
+	path := s.getAtomPath(atom.ID, codec)
 
-	data, err := yaml.Marshal(atom)
+	data, err := codec.Marshal(atom)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal atom to YAML: %w", err)
+		return "", err
 	}
 
-	if err := os.WriteFile(yamlPath, data, 0644); err != nil {
+	if err := os.WriteFile(path, data, 0644); err != nil {
 		return "", fmt.Errorf("failed to write atom file: %w", err)
 	}
 
-	// Clean up legacy JSON file if it exists
-	if _, err := os.Stat(jsonPath); err == nil {
-		os.Remove(jsonPath) // Best effort, ignore errors
+	for _, ext := range codecLoadOrder() {
+		if ext == codec.Extension() {
+			continue
+		}
+		other, _ := GetCodec(ext)
+		if stalePath := s.getAtomPath(atom.ID, other); stalePath != path {
+			os.Remove(stalePath) // Best effort, ignore errors
+		}
 	}
 
-	return yamlPath, nil
+	return path, nil
 }
 
-// Load loads an atom from disk.
-// Tries YAML first, falls back to JSON for backward compatibility.
-func (s *AtomStorage) Load(atomID string) (*models.Atom, error) {
-	yamlPath := s.getAtomPathYAML(atomID)
-	jsonPath := s.getAtomPathJSON(atomID)
+// Load loads an atom from disk, trying each registered codec in turn
+// (YAML, JSON, TOML, then CBOR; JSON keeps atoms written before YAML became
+// the default readable). Content-hash-ID atoms are verified
+// against their own ID, returning *ErrCorrupt on a mismatch.
+func (s *FilesystemStorage) Load(atomID string) (*models.Atom, error) {
+	var lastErr error
+	for _, ext := range codecLoadOrder() {
+		codec, _ := GetCodec(ext)
+		data, err := os.ReadFile(s.getAtomPath(atomID, codec))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			lastErr = fmt.Errorf("failed to read atom file: %w", err)
+			continue
+		}
 
-	// Try YAML first
-	if data, err := os.ReadFile(yamlPath); err == nil {
 		var atom models.Atom
-		if err := yaml.Unmarshal(data, &atom); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal YAML atom: %w", err)
+		if err := codec.Unmarshal(data, &atom); err != nil {
+			return nil, err
 		}
-		return &atom, nil
-	}
-
-	// Fall back to JSON
-	data, err := os.ReadFile(jsonPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
+		if err := verifyContentHash(&atom); err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("failed to read atom file: %w", err)
-	}
-
-	var atom models.Atom
-	if err := json.Unmarshal(data, &atom); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal JSON atom: %w", err)
+		return &atom, nil
 	}
 
-	return &atom, nil
+	return nil, lastErr
 }
 
-// Delete deletes an atom file from disk (both YAML and JSON versions).
-func (s *AtomStorage) Delete(atomID string) (bool, error) {
-	yamlPath := s.getAtomPathYAML(atomID)
-	jsonPath := s.getAtomPathJSON(atomID)
-
-	yamlExists := false
-	jsonExists := false
-
-	if _, err := os.Stat(yamlPath); err == nil {
-		yamlExists = true
-	}
-	if _, err := os.Stat(jsonPath); err == nil {
-		jsonExists = true
-	}
+// Delete deletes an atom's files across every registered codec's format.
+func (s *FilesystemStorage) Delete(atomID string) (bool, error) {
+	existed := false
 
-	if !yamlExists && !jsonExists {
-		return false, nil
-	}
-
-	if yamlExists {
-		if err := os.Remove(yamlPath); err != nil {
-			return false, fmt.Errorf("failed to delete YAML atom file: %w", err)
+	for _, ext := range codecLoadOrder() {
+		codec, _ := GetCodec(ext)
+		path := s.getAtomPath(atomID, codec)
+		if _, err := os.Stat(path); err != nil {
+			continue
 		}
-	}
-	if jsonExists {
-		if err := os.Remove(jsonPath); err != nil {
-			return false, fmt.Errorf("failed to delete JSON atom file: %w", err)
+		existed = true
+		if err := os.Remove(path); err != nil {
+			return false, fmt.Errorf("failed to delete %s atom file: %w", ext, err)
 		}
 	}
 
-	return true, nil
+	return existed, nil
 }
 
-// Exists checks if an atom file exists (YAML or JSON).
-func (s *AtomStorage) Exists(atomID string) bool {
-	yamlPath := s.getAtomPathYAML(atomID)
-	jsonPath := s.getAtomPathJSON(atomID)
-
-	if _, err := os.Stat(yamlPath); err == nil {
-		return true
-	}
-	if _, err := os.Stat(jsonPath); err == nil {
-		return true
+// Exists checks if an atom file exists under any registered codec.
+func (s *FilesystemStorage) Exists(atomID string) bool {
+	for _, ext := range codecLoadOrder() {
+		codec, _ := GetCodec(ext)
+		if _, err := os.Stat(s.getAtomPath(atomID, codec)); err == nil {
+			return true
+		}
 	}
 	return false
 }
 
 // ListAllIDs lists all atom IDs in storage.
-func (s *AtomStorage) ListAllIDs() ([]string, error) {
+func (s *FilesystemStorage) ListAllIDs() ([]string, error) {
 	atomsPath := s.config.AtomsPath()
 
 	if _, err := os.Stat(atomsPath); os.IsNotExist(err) {
@@ -155,7 +152,8 @@ func (s *AtomStorage) ListAllIDs() ([]string, error) {
 		return nil, fmt.Errorf("failed to read atoms directory: %w", err)
 	}
 
-	// Use a map to deduplicate IDs (in case both .yaml and .json exist)
+	// Use a map to deduplicate IDs (in case the same atom has files under
+	// more than one registered codec's extension)
 	idSet := make(map[string]bool)
 	for _, entry := range entries {
 		if entry.IsDir() {
@@ -165,10 +163,11 @@ func (s *AtomStorage) ListAllIDs() ([]string, error) {
 		if !strings.HasPrefix(name, "K-") {
 			continue
 		}
-		if strings.HasSuffix(name, ".yaml") {
-			idSet[strings.TrimSuffix(name, ".yaml")] = true
-		} else if strings.HasSuffix(name, ".json") {
-			idSet[strings.TrimSuffix(name, ".json")] = true
+		for _, ext := range codecLoadOrder() {
+			if suffix := "." + ext; strings.HasSuffix(name, suffix) {
+				idSet[strings.TrimSuffix(name, suffix)] = true
+				break
+			}
 		}
 	}
 
@@ -179,3 +178,27 @@ func (s *AtomStorage) ListAllIDs() ([]string, error) {
 
 	return ids, nil
 }
+
+// Walk streams every atom on disk through fn, stopping (and returning the
+// error) if fn or the underlying Load fails.
+func (s *FilesystemStorage) Walk(fn WalkFunc) error {
+	ids, err := s.ListAllIDs()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		atom, err := s.Load(id)
+		if err != nil {
+			return fmt.Errorf("failed to load atom %s: %w", id, err)
+		}
+		if atom == nil {
+			continue
+		}
+		if err := fn(atom); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// maxExtractBytes bounds how much of an attachment we read for indexing
+// purposes, so a large PDF or log file can't blow up search latency.
+const maxExtractBytes = 1 << 20 // 1 MiB
+
+// AttachmentExtractor pulls indexable text out of an attachment's bytes.
+type AttachmentExtractor func(r io.Reader) (string, error)
+
+var attachmentExtractors = map[string]AttachmentExtractor{}
+
+// RegisterAttachmentExtractor registers an extractor for a MIME type or
+// MIME type prefix (e.g. "text/" matches "text/plain", "text/markdown", ...).
+func RegisterAttachmentExtractor(mimePrefix string, extractor AttachmentExtractor) {
+	attachmentExtractors[mimePrefix] = extractor
+}
+
+func init() {
+	RegisterAttachmentExtractor("text/", extractPlainText)
+	RegisterAttachmentExtractor("application/pdf", extractPDFBestEffort)
+}
+
+// ExtractAttachmentText returns the indexable text for an attachment's
+// content, using the extractor registered for the most specific matching
+// MIME type/prefix. Returns ("", nil) if no extractor applies.
+func ExtractAttachmentText(mimeType string, r io.Reader) (string, error) {
+	if extractor, ok := attachmentExtractors[mimeType]; ok {
+		return extractor(io.LimitReader(r, maxExtractBytes))
+	}
+	for prefix, extractor := range attachmentExtractors {
+		if strings.HasSuffix(prefix, "/") && strings.HasPrefix(mimeType, prefix) {
+			return extractor(io.LimitReader(r, maxExtractBytes))
+		}
+	}
+	return "", nil
+}
+
+func extractPlainText(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// extractPDFBestEffort does not parse the PDF object model - that needs a
+// real library - but strips binary noise and keeps runs of printable
+// characters, which is enough to pick up embedded text streams in
+// uncompressed PDFs for search purposes. It is intentionally named
+// "best effort": it will miss text in compressed object streams.
+func extractPDFBestEffort(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	var run bytes.Buffer
+	flush := func() {
+		if run.Len() >= 4 {
+			out.Write(run.Bytes())
+			out.WriteByte(' ')
+		}
+		run.Reset()
+	}
+	for _, b := range data {
+		if b >= 0x20 && b < 0x7f {
+			run.WriteByte(b)
+		} else {
+			flush()
+		}
+	}
+	flush()
+	return out.String(), nil
+}
@@ -0,0 +1,274 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sephriot/knowledge-mcp/models"
+)
+
+// attachmentsDir returns the directory holding attachment bytes for an atom.
+func (s *FilesystemStorage) attachmentsDir(atomID string) string {
+	return filepath.Join(s.config.AtomsPath(), "attachments", atomID)
+}
+
+// attachmentPath returns the on-disk path for a single attachment's bytes.
+func (s *FilesystemStorage) attachmentPath(atomID, attachmentID string) string {
+	return filepath.Join(s.attachmentsDir(atomID), attachmentID)
+}
+
+// nextAttachmentID finds the next sequential attachment ID for an atom,
+// following the same "scan existing, take max+1" convention as
+// Index.GetNextID.
+func nextAttachmentID(existing []models.Attachment) string {
+	maxNum := 0
+	for _, a := range existing {
+		var num int
+		n, _ := fmt.Sscanf(a.ID, "ATT-%d", &num)
+		if n == 1 && num > maxNum {
+			maxNum = num
+		}
+	}
+	return fmt.Sprintf("ATT-%06d", maxNum+1)
+}
+
+// attachmentWriter wraps the on-disk file so bytes are hashed as they're
+// written; on Close it finalizes the attachment's size/sha256 into the
+// atom's metadata.
+type attachmentWriter struct {
+	f          *os.File
+	hash       hash.Hash
+	size       int64
+	storage    *FilesystemStorage
+	atomID     string
+	attachment *models.Attachment
+	sumFunc    func() string
+}
+
+func (w *attachmentWriter) Write(p []byte) (int, error) {
+	n, err := w.f.Write(p)
+	if n > 0 {
+		w.hash.Write(p[:n])
+		w.size += int64(n)
+	}
+	return n, err
+}
+
+func (w *attachmentWriter) Close() error {
+	if err := w.f.Close(); err != nil {
+		return fmt.Errorf("failed to close attachment file: %w", err)
+	}
+	w.attachment.Size = w.size
+	w.attachment.SHA256 = w.sumFunc()
+	return w.storage.finalizeAttachment(w.atomID, w.attachment)
+}
+
+// finalizeAttachment loads the atom, upserts the attachment's metadata
+// (by ID), and saves it back.
+func (s *FilesystemStorage) finalizeAttachment(atomID string, attachment *models.Attachment) error {
+	atom, err := s.Load(atomID)
+	if err != nil {
+		return err
+	}
+	if atom == nil {
+		return fmt.Errorf("atom %s not found", atomID)
+	}
+
+	found := false
+	for i, a := range atom.Attachments {
+		if a.ID == attachment.ID {
+			atom.Attachments[i] = *attachment
+			found = true
+			break
+		}
+	}
+	if !found {
+		atom.Attachments = append(atom.Attachments, *attachment)
+	}
+
+	_, err = s.Save(atom)
+	return err
+}
+
+// CreateAttachment registers a new attachment on atomID and returns a
+// writer for its bytes. The returned Attachment's Size/SHA256 are only
+// populated once the writer is closed.
+func (s *FilesystemStorage) CreateAttachment(atomID, name, mimeType string) (io.WriteCloser, *models.Attachment, error) {
+	atom, err := s.Load(atomID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if atom == nil {
+		return nil, nil, fmt.Errorf("atom %s not found", atomID)
+	}
+
+	dir := s.attachmentsDir(atomID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create attachments directory: %w", err)
+	}
+
+	attachment := &models.Attachment{
+		ID:        nextAttachmentID(atom.Attachments),
+		Name:      name,
+		MimeType:  mimeType,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	f, err := os.Create(s.attachmentPath(atomID, attachment.ID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create attachment file: %w", err)
+	}
+
+	h := sha256.New()
+	w := &attachmentWriter{
+		f:          f,
+		hash:       h,
+		storage:    s,
+		atomID:     atomID,
+		attachment: attachment,
+		sumFunc:    func() string { return hex.EncodeToString(h.Sum(nil)) },
+	}
+
+	return w, attachment, nil
+}
+
+// AppendAttachment returns a writer that appends bytes to an existing
+// attachment, updating its size/sha256 on Close.
+func (s *FilesystemStorage) AppendAttachment(atomID, attachmentID string) (io.WriteCloser, error) {
+	atom, err := s.Load(atomID)
+	if err != nil {
+		return nil, err
+	}
+	if atom == nil {
+		return nil, fmt.Errorf("atom %s not found", atomID)
+	}
+
+	var attachment *models.Attachment
+	for i := range atom.Attachments {
+		if atom.Attachments[i].ID == attachmentID {
+			attachment = &atom.Attachments[i]
+			break
+		}
+	}
+	if attachment == nil {
+		return nil, fmt.Errorf("attachment %s not found on atom %s", attachmentID, atomID)
+	}
+
+	path := s.attachmentPath(atomID, attachmentID)
+
+	// Seed the hash with the existing bytes so Close() reports the hash
+	// of the full (pre-existing + appended) content, not just the delta.
+	h := sha256.New()
+	if existing, err := os.ReadFile(path); err == nil {
+		h.Write(existing)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open attachment file for append: %w", err)
+	}
+
+	attachmentCopy := *attachment
+	w := &attachmentWriter{
+		f:          f,
+		hash:       h,
+		size:       attachment.Size,
+		storage:    s,
+		atomID:     atomID,
+		attachment: &attachmentCopy,
+		sumFunc:    func() string { return hex.EncodeToString(h.Sum(nil)) },
+	}
+
+	return w, nil
+}
+
+// OpenAttachment opens an attachment's bytes for reading, along with its
+// metadata.
+func (s *FilesystemStorage) OpenAttachment(atomID, attachmentID string) (io.ReadCloser, *models.Attachment, error) {
+	atom, err := s.Load(atomID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if atom == nil {
+		return nil, nil, fmt.Errorf("atom %s not found", atomID)
+	}
+
+	var attachment *models.Attachment
+	for _, a := range atom.Attachments {
+		if a.ID == attachmentID {
+			copy := a
+			attachment = &copy
+			break
+		}
+	}
+	if attachment == nil {
+		return nil, nil, fmt.Errorf("attachment %s not found on atom %s", attachmentID, atomID)
+	}
+
+	f, err := os.Open(s.attachmentPath(atomID, attachmentID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open attachment file: %w", err)
+	}
+
+	return f, attachment, nil
+}
+
+// DeleteAttachment removes an attachment's bytes and metadata.
+func (s *FilesystemStorage) DeleteAttachment(atomID, attachmentID string) (bool, error) {
+	atom, err := s.Load(atomID)
+	if err != nil {
+		return false, err
+	}
+	if atom == nil {
+		return false, fmt.Errorf("atom %s not found", atomID)
+	}
+
+	found := false
+	remaining := atom.Attachments[:0]
+	for _, a := range atom.Attachments {
+		if a.ID == attachmentID {
+			found = true
+			continue
+		}
+		remaining = append(remaining, a)
+	}
+	if !found {
+		return false, nil
+	}
+	atom.Attachments = remaining
+
+	path := s.attachmentPath(atomID, attachmentID)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to delete attachment file: %w", err)
+	}
+
+	if _, err := s.Save(atom); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListAttachments returns the attachment metadata for an atom.
+func (s *FilesystemStorage) ListAttachments(atomID string) ([]models.Attachment, error) {
+	atom, err := s.Load(atomID)
+	if err != nil {
+		return nil, err
+	}
+	if atom == nil {
+		return nil, fmt.Errorf("atom %s not found", atomID)
+	}
+	return atom.Attachments, nil
+}
+
+// isTextLike reports whether a MIME type should be treated as plain text
+// for content extraction purposes.
+func isTextLike(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/")
+}
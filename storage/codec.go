@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fxamacker/cbor/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sephriot/knowledge-mcp/models"
+)
+
+// Codec marshals and unmarshals atoms to/from a specific on-disk encoding.
+// Integrators can plug in additional formats (e.g. TOML for human-editable
+// atoms, CBOR for compact embedded storage) via RegisterCodec without
+// forking the storage package.
+type Codec interface {
+	// Extension returns the file extension this codec reads and writes,
+	// without a leading dot (e.g. "yaml").
+	Extension() string
+
+	// Marshal encodes atom into this codec's format.
+	Marshal(atom *models.Atom) ([]byte, error)
+
+	// Unmarshal decodes data (in this codec's format) into atom.
+	Unmarshal(data []byte, atom *models.Atom) error
+}
+
+var (
+	codecs     = make(map[string]Codec)
+	codecOrder []string
+)
+
+// RegisterCodec registers codec under its Extension(), making it available
+// to GetCodec and FilesystemStorage.Load's fallback chain. Re-registering an
+// already-known extension replaces the codec without changing its position
+// in the fallback order. Mirrors the registration pattern used by packagers
+// like nfpm's RegisterPackager.
+func RegisterCodec(codec Codec) {
+	ext := codec.Extension()
+	if _, exists := codecs[ext]; !exists {
+		codecOrder = append(codecOrder, ext)
+	}
+	codecs[ext] = codec
+}
+
+// GetCodec returns the codec registered for ext (without a leading dot), or
+// (nil, false) if none is registered.
+func GetCodec(ext string) (Codec, bool) {
+	codec, ok := codecs[ext]
+	return codec, ok
+}
+
+// codecLoadOrder returns the extensions FilesystemStorage.Load tries, in
+// order, when reading an atom back from disk: registration order, which for
+// the built-in codecs is "yaml", "json", "toml", then "cbor". A codec
+// registered later by an integrator is tried after them.
+func codecLoadOrder() []string {
+	return codecOrder
+}
+
+func init() {
+	RegisterCodec(yamlCodec{})
+	RegisterCodec(jsonCodec{})
+	RegisterCodec(tomlCodec{})
+	RegisterCodec(cborCodec{})
+}
+
+// yamlCodec is the default, human-editable atom encoding.
+type yamlCodec struct{}
+
+func (yamlCodec) Extension() string { return "yaml" }
+
+func (yamlCodec) Marshal(atom *models.Atom) ([]byte, error) {
+	data, err := yaml.Marshal(atom)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal atom to YAML: %w", err)
+	}
+	return data, nil
+}
+
+func (yamlCodec) Unmarshal(data []byte, atom *models.Atom) error {
+	if err := yaml.Unmarshal(data, atom); err != nil {
+		return fmt.Errorf("failed to unmarshal YAML atom: %w", err)
+	}
+	return nil
+}
+
+// jsonCodec is the legacy encoding atoms were stored as before YAML became
+// the default. It's kept registered so Load can still read old atom files.
+type jsonCodec struct{}
+
+func (jsonCodec) Extension() string { return "json" }
+
+func (jsonCodec) Marshal(atom *models.Atom) ([]byte, error) {
+	data, err := json.MarshalIndent(atom, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal atom to JSON: %w", err)
+	}
+	return data, nil
+}
+
+func (jsonCodec) Unmarshal(data []byte, atom *models.Atom) error {
+	if err := json.Unmarshal(data, atom); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON atom: %w", err)
+	}
+	return nil
+}
+
+// tomlCodec is a human-editable encoding, for integrators who prefer TOML's
+// table syntax over YAML's indentation-sensitive one.
+type tomlCodec struct{}
+
+func (tomlCodec) Extension() string { return "toml" }
+
+func (tomlCodec) Marshal(atom *models.Atom) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(atom); err != nil {
+		return nil, fmt.Errorf("failed to marshal atom to TOML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (tomlCodec) Unmarshal(data []byte, atom *models.Atom) error {
+	if _, err := toml.Decode(string(data), atom); err != nil {
+		return fmt.Errorf("failed to unmarshal TOML atom: %w", err)
+	}
+	return nil
+}
+
+// cborCodec is a compact binary encoding, for embedded or bandwidth-
+// constrained deployments where atom files' on-disk size matters more than
+// human readability.
+type cborCodec struct{}
+
+func (cborCodec) Extension() string { return "cbor" }
+
+func (cborCodec) Marshal(atom *models.Atom) ([]byte, error) {
+	data, err := cbor.Marshal(atom)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal atom to CBOR: %w", err)
+	}
+	return data, nil
+}
+
+func (cborCodec) Unmarshal(data []byte, atom *models.Atom) error {
+	if err := cbor.Unmarshal(data, atom); err != nil {
+		return fmt.Errorf("failed to unmarshal CBOR atom: %w", err)
+	}
+	return nil
+}
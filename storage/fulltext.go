@@ -0,0 +1,549 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/RoaringBitmap/roaring"
+
+	"github.com/sephriot/knowledge-mcp/config"
+	"github.com/sephriot/knowledge-mcp/models"
+)
+
+// Field identifies which part of an atom a posting was extracted from.
+type Field string
+
+const (
+	FieldTitle   Field = "title"
+	FieldTag     Field = "tag"
+	FieldSummary Field = "summary"
+	FieldDetails Field = "details"
+)
+
+// FieldWeight returns the BM25F field weight used when blending per-field
+// scores. Title matches dominate, tags come next, then summary/details.
+func FieldWeight(f Field) float64 {
+	switch f {
+	case FieldTitle:
+		return 3.0
+	case FieldTag:
+		return 2.0
+	case FieldSummary:
+		return 1.0
+	case FieldDetails:
+		return 0.5
+	default:
+		return 1.0
+	}
+}
+
+// Posting records a single token occurrence within one field of one atom.
+type Posting struct {
+	AtomID    string `json:"atom_id"`
+	Field     Field  `json:"field"`
+	TermFreq  int    `json:"term_freq"`
+	Positions []int  `json:"positions"`
+}
+
+// fieldStats tracks the token length of a single field for a single atom.
+type fieldStats struct {
+	Length int `json:"length"`
+}
+
+// FullTextIndex is an in-memory (and persisted) inverted index over atom
+// titles, tags, summaries, and details, used to compute BM25 relevance
+// scores without re-scanning every atom on every search.
+type FullTextIndex struct {
+	config *config.Config
+	mu     sync.RWMutex
+
+	// Postings maps a normalized token to every place it occurs.
+	Postings map[string][]Posting `json:"postings"`
+
+	// FieldLengths maps atomID -> field -> token count, used to compute
+	// the length-normalization term in BM25.
+	FieldLengths map[string]map[Field]fieldStats `json:"field_lengths"`
+
+	// TotalFieldLength/DocsWithField back the average-field-length terms.
+	TotalFieldLength map[Field]int `json:"total_field_length"`
+	DocsWithField    map[Field]int `json:"docs_with_field"`
+
+	// DocFreq is the number of atoms each token appears in (any field),
+	// used for IDF.
+	DocFreq map[string]int `json:"doc_freq"`
+
+	// DocCount is the total number of indexed atoms.
+	DocCount int `json:"doc_count"`
+
+	// Version mirrors the source index's UpdatedAt so SearchEngine can
+	// tell whether this fulltext index is stale.
+	Version string `json:"version"`
+
+	// Fuzzy-match support: a trigram index over title+tag tokens plus the
+	// distinct words per atom, used to prefilter and then verify typo
+	// matches. Rebuilt alongside the BM25 postings rather than persisted,
+	// since roaring.Bitmap needs its own (de)serialization.
+	trigrams     map[string]*roaring.Bitmap
+	atomWords    map[string]map[string]bool
+	atomOrdinals map[string]uint32
+	ordinalAtoms map[uint32]string
+	nextOrdinal  uint32
+}
+
+// stopwords is a small, deliberately short list - enough to keep noise
+// words from dominating short queries without dragging in a dictionary.
+var stopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true,
+	"to": true, "in": true, "on": true, "is": true, "it": true, "for": true,
+	"with": true, "as": true, "at": true, "by": true, "be": true, "this": true,
+	"that": true,
+}
+
+// Tokenize splits text into lowercased, punctuation-stripped tokens using
+// Unicode letter/digit boundaries, drops stopwords, and applies a small
+// suffix-stripping stemmer (full Snowball is overkill for short atoms).
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, f := range fields {
+		t := strings.ToLower(f)
+		if t == "" || stopwords[t] {
+			continue
+		}
+		tokens = append(tokens, stem(t))
+	}
+	return tokens
+}
+
+// stem applies a handful of common suffix-stripping rules. It is not a
+// full Snowball implementation, but it folds plurals and simple verb
+// endings together, which covers most of the benefit for short queries.
+func stem(token string) string {
+	switch {
+	case len(token) > 4 && strings.HasSuffix(token, "ies"):
+		return token[:len(token)-3] + "y"
+	case len(token) > 4 && strings.HasSuffix(token, "ing"):
+		return token[:len(token)-3]
+	case len(token) > 3 && strings.HasSuffix(token, "ed"):
+		return token[:len(token)-2]
+	case len(token) > 3 && strings.HasSuffix(token, "es"):
+		return token[:len(token)-2]
+	case len(token) > 3 && strings.HasSuffix(token, "s") && !strings.HasSuffix(token, "ss"):
+		return token[:len(token)-1]
+	default:
+		return token
+	}
+}
+
+// NewFullTextIndex creates an empty full-text index.
+func NewFullTextIndex(cfg *config.Config) *FullTextIndex {
+	if cfg == nil {
+		cfg = config.GetConfig()
+	}
+	return &FullTextIndex{
+		config:           cfg,
+		Postings:         make(map[string][]Posting),
+		FieldLengths:     make(map[string]map[Field]fieldStats),
+		TotalFieldLength: make(map[Field]int),
+		DocsWithField:    make(map[Field]int),
+		DocFreq:          make(map[string]int),
+		trigrams:         make(map[string]*roaring.Bitmap),
+		atomWords:        make(map[string]map[string]bool),
+		atomOrdinals:     make(map[string]uint32),
+		ordinalAtoms:     make(map[uint32]string),
+	}
+}
+
+// rebuildFuzzyIndexLocked reconstructs the trigram/word-set fuzzy-match
+// structures from the (already loaded) BM25 postings, restricted to the
+// title and tag fields. Caller must hold the lock.
+func (f *FullTextIndex) rebuildFuzzyIndexLocked() {
+	f.trigrams = make(map[string]*roaring.Bitmap)
+	f.atomWords = make(map[string]map[string]bool)
+	f.atomOrdinals = make(map[string]uint32)
+	f.ordinalAtoms = make(map[uint32]string)
+	f.nextOrdinal = 0
+
+	for tok, postings := range f.Postings {
+		for _, p := range postings {
+			if p.Field != FieldTitle && p.Field != FieldTag {
+				continue
+			}
+			f.fuzzyIndexField(p.AtomID, []string{tok})
+		}
+	}
+}
+
+// fullTextPath returns the path to the persisted inverted index.
+func (f *FullTextIndex) fullTextPath() string {
+	return f.config.FullTextIndexPath()
+}
+
+// Load reads a previously persisted index from disk. A missing file is not
+// an error - it just means the index has not been built yet.
+func (f *FullTextIndex) Load() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := os.ReadFile(f.fullTextPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read fulltext index: %w", err)
+	}
+
+	var loaded FullTextIndex
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to unmarshal fulltext index: %w", err)
+	}
+
+	f.Postings = loaded.Postings
+	f.FieldLengths = loaded.FieldLengths
+	f.TotalFieldLength = loaded.TotalFieldLength
+	f.DocsWithField = loaded.DocsWithField
+	f.DocFreq = loaded.DocFreq
+	f.DocCount = loaded.DocCount
+	f.Version = loaded.Version
+	f.rebuildFuzzyIndexLocked()
+	return nil
+}
+
+// Save persists the index to disk.
+func (f *FullTextIndex) Save() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.saveLocked()
+}
+
+func (f *FullTextIndex) saveLocked() error {
+	if err := f.config.EnsureDirs(); err != nil {
+		return fmt.Errorf("failed to create directories: %w", err)
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("failed to marshal fulltext index: %w", err)
+	}
+
+	if err := os.WriteFile(f.fullTextPath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write fulltext index: %w", err)
+	}
+	return nil
+}
+
+// indexField tokenizes text and records postings for it under the given
+// field, returning the token count (field length).
+func (f *FullTextIndex) indexField(atomID string, field Field, text string, seen map[string]bool) int {
+	tokens := Tokenize(text)
+	positions := make(map[string][]int)
+	for pos, tok := range tokens {
+		positions[tok] = append(positions[tok], pos)
+	}
+
+	for tok, positionList := range positions {
+		f.Postings[tok] = append(f.Postings[tok], Posting{
+			AtomID:    atomID,
+			Field:     field,
+			TermFreq:  len(positionList),
+			Positions: positionList,
+		})
+		if !seen[tok] {
+			f.DocFreq[tok]++
+			seen[tok] = true
+		}
+	}
+
+	return len(tokens)
+}
+
+// AddOrUpdate (re)indexes a single atom, removing any prior postings for it
+// first so repeated updates don't leak stale entries.
+func (f *FullTextIndex) AddOrUpdate(atom *models.Atom) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.removeLocked(atom.ID)
+
+	seen := make(map[string]bool)
+	lengths := make(map[Field]fieldStats)
+
+	lengths[FieldTitle] = fieldStats{Length: f.indexField(atom.ID, FieldTitle, atom.Title, seen)}
+	lengths[FieldTag] = fieldStats{Length: f.indexField(atom.ID, FieldTag, strings.Join(atom.Tags, " "), seen)}
+	lengths[FieldSummary] = fieldStats{Length: f.indexField(atom.ID, FieldSummary, atom.Content.Summary, seen)}
+	lengths[FieldDetails] = fieldStats{Length: f.indexField(atom.ID, FieldDetails, atom.Content.Details, seen)}
+
+	f.FieldLengths[atom.ID] = lengths
+	for field, stats := range lengths {
+		f.TotalFieldLength[field] += stats.Length
+		if stats.Length > 0 {
+			f.DocsWithField[field]++
+		}
+	}
+	f.DocCount++
+
+	fuzzyTokens := append(Tokenize(atom.Title), Tokenize(strings.Join(atom.Tags, " "))...)
+	f.fuzzyIndexField(atom.ID, fuzzyTokens)
+}
+
+// Remove deletes all postings and stats for an atom.
+func (f *FullTextIndex) Remove(atomID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.removeLocked(atomID)
+}
+
+func (f *FullTextIndex) removeLocked(atomID string) {
+	lengths, ok := f.FieldLengths[atomID]
+	if !ok {
+		return
+	}
+
+	if ordinal, ok := f.atomOrdinals[atomID]; ok {
+		for _, bm := range f.trigrams {
+			bm.Remove(ordinal)
+		}
+		delete(f.atomWords, atomID)
+		delete(f.atomOrdinals, atomID)
+		delete(f.ordinalAtoms, ordinal)
+	}
+
+	for tok, postings := range f.Postings {
+		filtered := postings[:0]
+		hadToken := false
+		for _, p := range postings {
+			if p.AtomID == atomID {
+				hadToken = true
+				continue
+			}
+			filtered = append(filtered, p)
+		}
+		if hadToken {
+			f.DocFreq[tok]--
+			if f.DocFreq[tok] <= 0 {
+				delete(f.DocFreq, tok)
+			}
+		}
+		if len(filtered) == 0 {
+			delete(f.Postings, tok)
+		} else {
+			f.Postings[tok] = filtered
+		}
+	}
+
+	for field, stats := range lengths {
+		f.TotalFieldLength[field] -= stats.Length
+		if stats.Length > 0 {
+			f.DocsWithField[field]--
+		}
+	}
+	delete(f.FieldLengths, atomID)
+	f.DocCount--
+}
+
+// RebuildFromAtoms discards the current index and rebuilds it from scratch
+// by loading every atom via the given storage.
+func (f *FullTextIndex) RebuildFromAtoms(atomStorage AtomStorage, ids []string, version string) error {
+	f.mu.Lock()
+	f.Postings = make(map[string][]Posting)
+	f.FieldLengths = make(map[string]map[Field]fieldStats)
+	f.TotalFieldLength = make(map[Field]int)
+	f.DocsWithField = make(map[Field]int)
+	f.DocFreq = make(map[string]int)
+	f.DocCount = 0
+	f.trigrams = make(map[string]*roaring.Bitmap)
+	f.atomWords = make(map[string]map[string]bool)
+	f.atomOrdinals = make(map[string]uint32)
+	f.ordinalAtoms = make(map[uint32]string)
+	f.nextOrdinal = 0
+	f.mu.Unlock()
+
+	for _, id := range ids {
+		atom, err := atomStorage.Load(id)
+		if err != nil || atom == nil {
+			continue
+		}
+		f.AddOrUpdate(atom)
+	}
+
+	f.mu.Lock()
+	f.Version = version
+	err := f.saveLocked()
+	f.mu.Unlock()
+	return err
+}
+
+// avgFieldLength returns the average token length of a field across atoms
+// that have a non-empty value for it (empty fields don't penalize others).
+func (f *FullTextIndex) avgFieldLength(field Field) float64 {
+	n := f.DocsWithField[field]
+	if n == 0 {
+		return 0
+	}
+	return float64(f.TotalFieldLength[field]) / float64(n)
+}
+
+// idf computes the standard BM25 inverse document frequency for a token,
+// floored at a small positive epsilon so unseen tokens don't produce
+// negative scores.
+func (f *FullTextIndex) idf(token string) float64 {
+	n := float64(f.DocCount)
+	df := float64(f.DocFreq[token])
+	if n == 0 {
+		return 0
+	}
+	idf := math.Log((n-df+0.5)/(df+0.5) + 1)
+	if idf < 0 {
+		return 0.01
+	}
+	return idf
+}
+
+// postingsFor returns the postings for a token restricted to one atom.
+func (f *FullTextIndex) postingsFor(token, atomID string) []Posting {
+	var result []Posting
+	for _, p := range f.Postings[token] {
+		if p.AtomID == atomID {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// BM25 parameters. k1 controls term-frequency saturation, b controls how
+// strongly field-length normalization is applied.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// proximityWindow is how close (in token positions, same field)
+	// consecutive query terms must occur to earn the phrase bonus.
+	proximityWindow = 5
+	proximityBonus  = 15.0
+)
+
+// TermScore is the per-term contribution to an atom's BM25 score, returned
+// by Explain for debugging.
+type TermScore struct {
+	Token string            `json:"token"`
+	IDF   float64           `json:"idf"`
+	Field map[Field]float64 `json:"field_scores"`
+	Total float64           `json:"total"`
+}
+
+// CandidateAtoms returns the set of atom IDs that contain at least one of
+// the query tokens, so callers can avoid scoring the whole corpus.
+func (f *FullTextIndex) CandidateAtoms(queryTokens []string) map[string]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	candidates := make(map[string]bool)
+	for _, tok := range queryTokens {
+		for _, p := range f.Postings[tok] {
+			candidates[p.AtomID] = true
+		}
+	}
+	return candidates
+}
+
+// Score computes the BM25F relevance score for one atom against the given
+// (already tokenized) query, blending per-field scores by FieldWeight and
+// adding a proximity bonus for adjacent query terms found near each other.
+func (f *FullTextIndex) Score(atomID string, queryTokens []string) (float64, []TermScore) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	lengths, ok := f.FieldLengths[atomID]
+	if !ok {
+		return 0, nil
+	}
+
+	var total float64
+	terms := make([]TermScore, 0, len(queryTokens))
+
+	for _, tok := range queryTokens {
+		idf := f.idf(tok)
+		fieldScores := make(map[Field]float64)
+		var termTotal float64
+
+		for _, field := range []Field{FieldTitle, FieldTag, FieldSummary, FieldDetails} {
+			postings := f.postingsFor(tok, atomID)
+			tf := 0
+			for _, p := range postings {
+				if p.Field == field {
+					tf += p.TermFreq
+				}
+			}
+			if tf == 0 {
+				continue
+			}
+
+			fieldLen := float64(lengths[field].Length)
+			avgLen := f.avgFieldLength(field)
+			if avgLen == 0 {
+				avgLen = fieldLen
+			}
+
+			numerator := float64(tf) * (bm25K1 + 1)
+			denominator := float64(tf) + bm25K1*(1-bm25B+bm25B*fieldLen/avgLen)
+			score := idf * (numerator / denominator) * FieldWeight(field)
+
+			fieldScores[field] = score
+			termTotal += score
+		}
+
+		if termTotal > 0 {
+			terms = append(terms, TermScore{Token: tok, IDF: idf, Field: fieldScores, Total: termTotal})
+			total += termTotal
+		}
+	}
+
+	total += f.proximityBonus(atomID, queryTokens)
+
+	return total, terms
+}
+
+// proximityBonus rewards atoms where consecutive query terms occur close
+// together in the same field, which is a weak phrase-match signal.
+func (f *FullTextIndex) proximityBonus(atomID string, queryTokens []string) float64 {
+	if len(queryTokens) < 2 {
+		return 0
+	}
+
+	var bonus float64
+	for _, field := range []Field{FieldTitle, FieldTag, FieldSummary, FieldDetails} {
+		for i := 0; i < len(queryTokens)-1; i++ {
+			posA := positionsIn(f.postingsFor(queryTokens[i], atomID), field)
+			posB := positionsIn(f.postingsFor(queryTokens[i+1], atomID), field)
+			if len(posA) == 0 || len(posB) == 0 {
+				continue
+			}
+			for _, a := range posA {
+				for _, b := range posB {
+					d := b - a
+					if d > 0 && d <= proximityWindow {
+						bonus += proximityBonus / float64(d)
+					}
+				}
+			}
+		}
+	}
+	return bonus
+}
+
+func positionsIn(postings []Posting, field Field) []int {
+	for _, p := range postings {
+		if p.Field == field {
+			return p.Positions
+		}
+	}
+	return nil
+}
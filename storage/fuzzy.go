@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"github.com/RoaringBitmap/roaring"
+)
+
+// trigramsOf returns the character trigrams of a token, padding short
+// tokens with boundary markers so 1-2 character differences near the
+// edges still produce overlapping trigrams.
+func trigramsOf(token string) []string {
+	padded := "$" + token + "$"
+	runes := []rune(padded)
+	if len(runes) < 3 {
+		return []string{padded}
+	}
+
+	trigrams := make([]string, 0, len(runes)-2)
+	for i := 0; i+3 <= len(runes); i++ {
+		trigrams = append(trigrams, string(runes[i:i+3]))
+	}
+	return trigrams
+}
+
+// maxEditDistance returns the maximum Damerau-Levenshtein distance a fuzzy
+// match is allowed before being rejected, scaled to token length: short
+// tokens tolerate no/one edit, longer ones tolerate two.
+func maxEditDistance(token string) int {
+	switch {
+	case len(token) < 4:
+		return 0
+	case len(token) <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// damerauLevenshtein computes the bounded edit distance between a and b,
+// allowing insertions, deletions, substitutions, and adjacent transpositions.
+// Returns a value > maxDist if the true distance exceeds maxDist (exact
+// value not needed in that case since the caller only checks the bound).
+func damerauLevenshtein(a, b string, maxDist int) int {
+	ar, br := []rune(a), []rune(b)
+	if abs(len(ar)-len(br)) > maxDist {
+		return maxDist + 1
+	}
+
+	da := make(map[rune]int)
+	maxLen := len(ar) + len(br) + 1
+	d := make([][]int, len(ar)+2)
+	for i := range d {
+		d[i] = make([]int, len(br)+2)
+	}
+
+	d[0][0] = maxLen
+	for i := 0; i <= len(ar); i++ {
+		d[i+1][0] = maxLen
+		d[i+1][1] = i
+	}
+	for j := 0; j <= len(br); j++ {
+		d[0][j+1] = maxLen
+		d[1][j+1] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		db := 0
+		for j := 1; j <= len(br); j++ {
+			i1 := da[br[j-1]]
+			j1 := db
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+				db = j
+			}
+			d[i+1][j+1] = min4(
+				d[i][j]+cost,
+				d[i+1][j]+1,
+				d[i][j+1]+1,
+				d[i1][j1]+(i-i1-1)+1+(j-j1-1),
+			)
+		}
+		da[ar[i-1]] = i
+	}
+
+	return d[len(ar)+1][len(br)+1]
+}
+
+func min4(a, b, c, d int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	if d < m {
+		m = d
+	}
+	return m
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// fuzzyIndexField records trigrams for each token of a field so
+// FuzzyCandidates can prefilter atoms before running edit-distance checks,
+// and records the distinct tokens themselves so the edit distance can be
+// verified against real words rather than every trigram match.
+func (f *FullTextIndex) fuzzyIndexField(atomID string, tokens []string) {
+	ordinal, ok := f.atomOrdinals[atomID]
+	if !ok {
+		ordinal = f.nextOrdinal
+		f.nextOrdinal++
+		f.atomOrdinals[atomID] = ordinal
+		f.ordinalAtoms[ordinal] = atomID
+	}
+
+	if f.atomWords[atomID] == nil {
+		f.atomWords[atomID] = make(map[string]bool)
+	}
+
+	for _, tok := range tokens {
+		f.atomWords[atomID][tok] = true
+		for _, tri := range trigramsOf(tok) {
+			bm, ok := f.trigrams[tri]
+			if !ok {
+				bm = roaring.New()
+				f.trigrams[tri] = bm
+			}
+			bm.Add(ordinal)
+		}
+	}
+}
+
+// FuzzyCandidates returns atom IDs whose title/tag tokens share enough
+// trigrams with queryToken to be worth a real edit-distance check.
+func (f *FullTextIndex) FuzzyCandidates(queryToken string) map[string]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	trigrams := trigramsOf(queryToken)
+	if len(trigrams) == 0 {
+		return nil
+	}
+
+	// Union (rather than strict intersection) of trigram postings: a typo
+	// may not share every trigram with the intended word, especially near
+	// the edit site, so ORing keeps recall while the edit-distance check
+	// afterwards handles precision.
+	result := roaring.New()
+	for _, tri := range trigrams {
+		if bm, ok := f.trigrams[tri]; ok {
+			result.Or(bm)
+		}
+	}
+
+	candidates := make(map[string]bool, int(result.GetCardinality()))
+	it := result.Iterator()
+	for it.HasNext() {
+		if atomID, ok := f.ordinalAtoms[it.Next()]; ok {
+			candidates[atomID] = true
+		}
+	}
+	return candidates
+}
+
+// FuzzyMatch finds the closest title/tag token on the given atom to
+// queryToken, verified with bounded Damerau-Levenshtein distance. It
+// returns the match score (0 if nothing within the edit budget matched).
+// maxDistOverride fixes the edit budget when >= 0; otherwise it is scaled
+// to the query token's length via maxEditDistance (the "auto" behavior).
+func (f *FullTextIndex) FuzzyMatch(atomID, queryToken string, maxDistOverride int) float64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	maxDist := maxEditDistance(queryToken)
+	if maxDistOverride >= 0 {
+		maxDist = maxDistOverride
+	}
+	if maxDist == 0 {
+		return 0
+	}
+
+	best := maxDist + 1
+	for word := range f.atomWords[atomID] {
+		if word == queryToken {
+			// Exact matches are scored by BM25, not here.
+			continue
+		}
+		dist := damerauLevenshtein(queryToken, word, maxDist)
+		if dist < best {
+			best = dist
+		}
+	}
+
+	if best > maxDist {
+		return 0
+	}
+	return 1 - float64(best)/float64(len(queryToken))
+}
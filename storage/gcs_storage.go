@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sephriot/knowledge-mcp/config"
+	"github.com/sephriot/knowledge-mcp/models"
+)
+
+// GCSStorage is an AtomStorage backed by a Google Cloud Storage bucket, so
+// a knowledge repo can be published from CI (e.g. "gsutil rsync") and
+// consumed read-only by many MCP clients without each of them needing a
+// local checkout.
+type GCSStorage struct {
+	config *config.Config
+	bucket string
+	prefix string
+
+	client *storage.Client
+
+	mu           sync.Mutex
+	lastGeneration int64
+}
+
+// NewGCSStorage creates a GCSStorage from a "gs://bucket/prefix" DataPath.
+func NewGCSStorage(cfg *config.Config) *GCSStorage {
+	bucket, prefix := parseBucketURL(cfg.DataPath, "gs://")
+
+	client, err := storage.NewClient(context.Background())
+	if err != nil {
+		// Deferring the error to the first real operation keeps
+		// NewGCSStorage's signature symmetric with NewFilesystemStorage,
+		// which also never fails at construction time.
+		fmt.Fprintf(os.Stderr, "Warning: failed to create GCS client: %v\n", err)
+	}
+
+	return &GCSStorage{config: cfg, bucket: bucket, prefix: prefix, client: client}
+}
+
+func (s *GCSStorage) atomObject(atomID string) string {
+	return path.Join(s.prefix, "atoms", atomID+".yaml")
+}
+
+func (s *GCSStorage) indexObject() string {
+	return path.Join(s.prefix, "index.yaml")
+}
+
+// Save uploads atom as a YAML object.
+func (s *GCSStorage) Save(atom *models.Atom) (string, error) {
+	data, err := yaml.Marshal(atom)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal atom to YAML: %w", err)
+	}
+
+	obj := s.client.Bucket(s.bucket).Object(s.atomObject(atom.ID))
+	w := obj.NewWriter(context.Background())
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return "", fmt.Errorf("failed to write atom object: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to finalize atom object: %w", err)
+	}
+
+	return fmt.Sprintf("gs://%s/%s", s.bucket, s.atomObject(atom.ID)), nil
+}
+
+// Load downloads and unmarshals an atom, returning (nil, nil) if it
+// doesn't exist.
+func (s *GCSStorage) Load(atomID string) (*models.Atom, error) {
+	r, err := s.client.Bucket(s.bucket).Object(s.atomObject(atomID)).NewReader(context.Background())
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read atom object: %w", err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read atom object: %w", err)
+	}
+
+	var atom models.Atom
+	if err := yaml.Unmarshal(data, &atom); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal atom: %w", err)
+	}
+	if err := verifyContentHash(&atom); err != nil {
+		return nil, err
+	}
+	return &atom, nil
+}
+
+// Delete removes an atom object, reporting whether it existed.
+func (s *GCSStorage) Delete(atomID string) (bool, error) {
+	if !s.Exists(atomID) {
+		return false, nil
+	}
+	if err := s.client.Bucket(s.bucket).Object(s.atomObject(atomID)).Delete(context.Background()); err != nil {
+		return false, fmt.Errorf("failed to delete atom object: %w", err)
+	}
+	return true, nil
+}
+
+// Exists reports whether an atom object is present.
+func (s *GCSStorage) Exists(atomID string) bool {
+	_, err := s.client.Bucket(s.bucket).Object(s.atomObject(atomID)).Attrs(context.Background())
+	return err == nil
+}
+
+// ListAllIDs lists every atom object's ID under the atoms/ prefix.
+func (s *GCSStorage) ListAllIDs() ([]string, error) {
+	atomsPrefix := path.Join(s.prefix, "atoms") + "/"
+
+	it := s.client.Bucket(s.bucket).Objects(context.Background(), &storage.Query{Prefix: atomsPrefix})
+	var ids []string
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list atom objects: %w", err)
+		}
+		name := strings.TrimPrefix(attrs.Name, atomsPrefix)
+		if strings.HasSuffix(name, ".yaml") {
+			ids = append(ids, strings.TrimSuffix(name, ".yaml"))
+		}
+	}
+	return ids, nil
+}
+
+// Walk streams every atom through fn in listing order.
+func (s *GCSStorage) Walk(fn WalkFunc) error {
+	ids, err := s.ListAllIDs()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		atom, err := s.Load(id)
+		if err != nil {
+			return fmt.Errorf("failed to load atom %s: %w", id, err)
+		}
+		if atom == nil {
+			continue
+		}
+		if err := fn(atom); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IndexCachePath returns the local path the bucket's index.yaml is mirrored
+// to between polls.
+func (s *GCSStorage) IndexCachePath() string {
+	return s.config.IndexPath()
+}
+
+// WatchIndex polls the bucket's index.yaml object on the given interval,
+// refreshing IndexCachePath and invoking onChange whenever the object's
+// generation differs from the last sync. It returns a stop function that
+// ends the polling goroutine.
+func (s *GCSStorage) WatchIndex(interval time.Duration, onChange func()) (stop func()) {
+	done := make(chan struct{})
+
+	sync := func() {
+		attrs, err := s.client.Bucket(s.bucket).Object(s.indexObject()).Attrs(context.Background())
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		changed := attrs.Generation != s.lastGeneration
+		s.mu.Unlock()
+		if !changed {
+			return
+		}
+
+		r, err := s.client.Bucket(s.bucket).Object(s.indexObject()).NewReader(context.Background())
+		if err != nil {
+			return
+		}
+		defer r.Close()
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return
+		}
+
+		if err := s.config.EnsureDirs(); err != nil {
+			return
+		}
+		if err := os.WriteFile(s.IndexCachePath(), data, 0644); err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.lastGeneration = attrs.Generation
+		s.mu.Unlock()
+
+		if onChange != nil {
+			onChange()
+		}
+	}
+
+	// Prime the cache synchronously so the first GetIndex call after
+	// startup doesn't race the first poll tick.
+	sync()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				sync()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// parseBucketURL splits a "<scheme>bucket/prefix" DataPath (e.g.
+// "gs://my-bucket/knowledge") into its bucket name and object prefix.
+func parseBucketURL(dataPath, scheme string) (bucket, prefix string) {
+	rest := strings.TrimPrefix(dataPath, scheme)
+	parts := strings.SplitN(rest, "/", 2)
+	bucket = parts[0]
+	if len(parts) == 2 {
+		prefix = strings.Trim(parts[1], "/")
+	}
+	return bucket, prefix
+}
@@ -19,6 +19,35 @@ type IndexManager struct {
 	config *config.Config
 	index  *models.Index
 	mu     sync.RWMutex
+
+	hooksMu sync.Mutex
+	hooks   []IndexChangeHook
+}
+
+// IndexChangeHook is invoked with an atom's ID after AddOrUpdate, Remove, or
+// a WithTx-based bulk operation commits a change to its index entry. Hooks
+// run after the index lock has been released, so they may safely call back
+// into the IndexManager (e.g. GetIndex) without deadlocking.
+type IndexChangeHook func(atomID string)
+
+// OnChange registers hook to be invoked on every future index change. See
+// graph.Graph.Attach for the motivating use case: keeping an in-memory
+// knowledge graph incrementally consistent without rescanning every atom.
+func (m *IndexManager) OnChange(hook IndexChangeHook) {
+	m.hooksMu.Lock()
+	defer m.hooksMu.Unlock()
+	m.hooks = append(m.hooks, hook)
+}
+
+// notify runs every registered hook for atomID. Caller must not hold m.mu.
+func (m *IndexManager) notify(atomID string) {
+	m.hooksMu.Lock()
+	hooks := m.hooks
+	m.hooksMu.Unlock()
+
+	for _, hook := range hooks {
+		hook(atomID)
+	}
 }
 
 // NewIndexManager creates a new index manager.
@@ -132,32 +161,165 @@ func (m *IndexManager) GetIndex() (*models.Index, error) {
 // AddOrUpdate adds or updates an entry in the index.
 func (m *IndexManager) AddOrUpdate(entry *models.IndexEntry) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	if err := m.loadLocked(); err != nil {
+		m.mu.Unlock()
 		return err
 	}
 	m.index.AddOrUpdate(entry)
-	return m.saveLocked()
+	err := m.saveLocked()
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	m.notify(entry.ID)
+	return nil
 }
 
 // Remove removes an entry from the index.
 func (m *IndexManager) Remove(atomID string) (bool, error) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	if err := m.loadLocked(); err != nil {
+		m.mu.Unlock()
 		return false, err
 	}
 	result := m.index.Remove(atomID)
+	var err error
 	if result {
-		if err := m.saveLocked(); err != nil {
-			return false, err
-		}
+		err = m.saveLocked()
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return false, err
+	}
+
+	if result {
+		m.notify(atomID)
 	}
 	return result, nil
 }
 
+// IndexTx stages index mutations for WithTx. Its changes are only visible to
+// later calls within the same transaction until the transaction commits -
+// a failed transaction never touches the live index.
+type IndexTx struct {
+	index   *models.Index
+	touched []string
+}
+
+// AddOrUpdate stages an add-or-update against the transaction's working copy.
+func (tx *IndexTx) AddOrUpdate(entry *models.IndexEntry) {
+	tx.index.AddOrUpdate(entry)
+	tx.touched = append(tx.touched, entry.ID)
+}
+
+// Remove stages a removal against the transaction's working copy.
+func (tx *IndexTx) Remove(atomID string) bool {
+	removed := tx.index.Remove(atomID)
+	if removed {
+		tx.touched = append(tx.touched, atomID)
+	}
+	return removed
+}
+
+// FindByID looks up an entry in the transaction's working copy, reflecting
+// any mutations already staged earlier in the same transaction.
+func (tx *IndexTx) FindByID(atomID string) *models.IndexEntry {
+	return tx.index.FindByID(atomID)
+}
+
+// WithTx runs fn against a staged copy of the index, acquiring the write
+// lock only once. If fn returns nil, the staged copy is committed
+// atomically - written to "index.yaml.tmp" then renamed over the real index
+// file - and becomes the live index. If fn returns an error, the staged
+// copy is discarded and the live index is left exactly as it was, making
+// bulk imports and migrations crash-safe.
+func (m *IndexManager) WithTx(fn func(tx *IndexTx) error) error {
+	m.mu.Lock()
+
+	if err := m.loadLocked(); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+
+	staged := m.index.Clone()
+	tx := &IndexTx{index: staged}
+	if err := fn(tx); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+
+	if err := m.writeIndexAtomicLocked(staged); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	m.index = staged
+	m.mu.Unlock()
+
+	for _, id := range tx.touched {
+		m.notify(id)
+	}
+	return nil
+}
+
+// writeIndexAtomicLocked persists index to disk atomically: it writes the
+// marshaled YAML to a temp file and renames it into place, so a crash
+// mid-write can never leave a truncated or partially-written index file.
+// Caller must hold the lock.
+func (m *IndexManager) writeIndexAtomicLocked(index *models.Index) error {
+	if err := m.config.EnsureDirs(); err != nil {
+		return fmt.Errorf("failed to create directories: %w", err)
+	}
+
+	indexPath := m.config.IndexPath()
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal index to YAML: %w", err)
+	}
+
+	tmpPath := indexPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp index file: %w", err)
+	}
+	if err := os.Rename(tmpPath, indexPath); err != nil {
+		return fmt.Errorf("failed to commit index file: %w", err)
+	}
+
+	// Clean up legacy JSON index file if it exists
+	if _, err := os.Stat(m.config.IndexPathJSON()); err == nil {
+		os.Remove(m.config.IndexPathJSON()) // Best effort, ignore errors
+	}
+
+	return nil
+}
+
+// BulkAddOrUpdate adds or updates many entries under a single write lock,
+// persisting the index once at the end instead of once per entry. This
+// turns an O(N^2) full-index rewrite into O(N) for large imports.
+func (m *IndexManager) BulkAddOrUpdate(entries []*models.IndexEntry) error {
+	return m.WithTx(func(tx *IndexTx) error {
+		for _, entry := range entries {
+			tx.AddOrUpdate(entry)
+		}
+		return nil
+	})
+}
+
+// BulkRemove removes many entries under a single write lock, persisting the
+// index once at the end. Returns the number of entries actually removed.
+func (m *IndexManager) BulkRemove(ids []string) (int, error) {
+	removed := 0
+	err := m.WithTx(func(tx *IndexTx) error {
+		for _, id := range ids {
+			if tx.Remove(id) {
+				removed++
+			}
+		}
+		return nil
+	})
+	return removed, err
+}
+
 // FindByID finds an entry by ID.
 func (m *IndexManager) FindByID(atomID string) (*models.IndexEntry, error) {
 	m.mu.Lock()
@@ -180,47 +342,24 @@ func (m *IndexManager) GetNextID() (string, error) {
 	return m.index.GetNextID(), nil
 }
 
-// RebuildFromAtoms rebuilds the index from atom files.
-func (m *IndexManager) RebuildFromAtoms(atomsPath string) (*models.Index, error) {
+// RebuildFromAtoms rebuilds the index from atom storage, discarding
+// whatever is currently in memory. It goes through atomStorage's
+// ListAllIDs/Load rather than walking the filesystem directly, so it works
+// identically against a local FilesystemStorage or a remote GCS/S3 backend.
+func (m *IndexManager) RebuildFromAtoms(atomStorage AtomStorage) (*models.Index, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	m.index = models.NewEmptyIndex()
 
-	if _, err := os.Stat(atomsPath); os.IsNotExist(err) {
-		if err := m.saveLocked(); err != nil {
-			return nil, err
-		}
-		return m.index, nil
-	}
-
-	storage := NewAtomStorage(m.config)
-
-	entries, err := os.ReadDir(atomsPath)
+	ids, err := atomStorage.ListAllIDs()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read atoms directory: %w", err)
-	}
-
-	// Collect unique atom IDs (may have both .yaml and .json for same atom)
-	idSet := make(map[string]bool)
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-		name := entry.Name()
-		if !strings.HasPrefix(name, "K-") {
-			continue
-		}
-		if strings.HasSuffix(name, ".yaml") {
-			idSet[strings.TrimSuffix(name, ".yaml")] = true
-		} else if strings.HasSuffix(name, ".json") {
-			idSet[strings.TrimSuffix(name, ".json")] = true
-		}
+		return nil, fmt.Errorf("failed to list atoms: %w", err)
 	}
 
 	var loadErrors []string
-	for atomID := range idSet {
-		atom, err := storage.Load(atomID)
+	for _, atomID := range ids {
+		atom, err := atomStorage.Load(atomID)
 		if err != nil {
 			loadErrors = append(loadErrors, fmt.Sprintf("%s: %v", atomID, err))
 			continue
@@ -247,8 +386,10 @@ func (m *IndexManager) RebuildFromAtoms(atomsPath string) (*models.Index, error)
 }
 
 // MigrateAndRebuild migrates all JSON atoms to YAML and rebuilds the index.
+// Legacy JSON atoms only ever existed on local disk, so this always uses
+// FilesystemStorage rather than the configured AtomStorage.
 func (m *IndexManager) MigrateAndRebuild(atomsPath string) (*models.Index, int, error) {
-	storage := NewAtomStorage(m.config)
+	storage := NewFilesystemStorage(m.config)
 
 	// Collect all JSON files that need migration
 	entries, err := os.ReadDir(atomsPath)
@@ -293,7 +434,7 @@ func (m *IndexManager) MigrateAndRebuild(atomsPath string) (*models.Index, int,
 	}
 
 	// Now rebuild the index
-	index, err := m.RebuildFromAtoms(atomsPath)
+	index, err := m.RebuildFromAtoms(storage)
 	return index, migrated, err
 }
 
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/sephriot/knowledge-mcp/models"
+)
+
+// ErrCorrupt is returned by AtomStorage.Load when a content-hash-ID atom's
+// content no longer matches the hash embedded in its ID - it was hand-edited
+// after being written, or corrupted in transit/storage.
+type ErrCorrupt struct {
+	AtomID string
+}
+
+func (e *ErrCorrupt) Error() string {
+	return fmt.Sprintf("atom %s failed content-hash verification (content does not match its ID)", e.AtomID)
+}
+
+// verifyContentHash checks atom against its own ID when the ID is
+// content-addressable (see models.IsContentHashID), returning *ErrCorrupt on
+// a mismatch. Atoms using the sequential ID scheme have nothing to verify
+// against and always pass.
+func verifyContentHash(atom *models.Atom) error {
+	if !models.IsContentHashID(atom.ID) {
+		return nil
+	}
+	if models.NewContentHashID(atom) != atom.ID {
+		return &ErrCorrupt{AtomID: atom.ID}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sephriot/knowledge-mcp/config"
+	"github.com/sephriot/knowledge-mcp/models"
+)
+
+// MemoryStorage is an AtomStorage implementation backed by an in-process
+// map, for ephemeral knowledge bases (tests, short-lived scripts) that
+// don't need atoms to outlive the process. Selected via a "mem://" DataPath
+// - everything after the scheme is ignored, since there's no bucket or
+// directory to address.
+type MemoryStorage struct {
+	mu    sync.RWMutex
+	atoms map[string]*models.Atom
+}
+
+// NewMemoryStorage creates an empty in-memory atom store.
+func NewMemoryStorage(cfg *config.Config) *MemoryStorage {
+	return &MemoryStorage{atoms: make(map[string]*models.Atom)}
+}
+
+// Save stores a shallow copy of atom (the struct itself, not its
+// slices/pointers), so reassigning the caller's atom fields afterwards
+// doesn't change what Load returns. Slices and pointers stay shared with the
+// caller, so mutating their elements in place does.
+func (s *MemoryStorage) Save(atom *models.Atom) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *atom
+	s.atoms[atom.ID] = &stored
+
+	return fmt.Sprintf("mem://%s", atom.ID), nil
+}
+
+// Load returns the stored atom by ID, or (nil, nil) if it doesn't exist.
+func (s *MemoryStorage) Load(atomID string) (*models.Atom, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	atom, ok := s.atoms[atomID]
+	if !ok {
+		return nil, nil
+	}
+
+	if err := verifyContentHash(atom); err != nil {
+		return nil, err
+	}
+
+	stored := *atom
+	return &stored, nil
+}
+
+// Delete removes an atom, reporting whether it existed.
+func (s *MemoryStorage) Delete(atomID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.atoms[atomID]; !ok {
+		return false, nil
+	}
+	delete(s.atoms, atomID)
+	return true, nil
+}
+
+// Exists reports whether an atom is present in the store.
+func (s *MemoryStorage) Exists(atomID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.atoms[atomID]
+	return ok
+}
+
+// ListAllIDs lists every atom ID in the store, sorted for deterministic
+// output.
+func (s *MemoryStorage) ListAllIDs() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.atoms))
+	for id := range s.atoms {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Walk streams every atom through fn in ID order.
+func (s *MemoryStorage) Walk(fn WalkFunc) error {
+	ids, err := s.ListAllIDs()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		atom, err := s.Load(id)
+		if err != nil {
+			return fmt.Errorf("failed to load atom %s: %w", id, err)
+		}
+		if atom == nil {
+			continue
+		}
+		if err := fn(atom); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sephriot/knowledge-mcp/config"
+	"github.com/sephriot/knowledge-mcp/models"
+)
+
+// S3Storage is an AtomStorage backed by an S3 (or S3-compatible) bucket -
+// the same "publish from CI, consume read-only from many clients" use case
+// as GCSStorage, for teams already standardized on AWS.
+type S3Storage struct {
+	config *config.Config
+	bucket string
+	prefix string
+
+	client *s3.Client
+
+	mu       sync.Mutex
+	lastETag string
+}
+
+// NewS3Storage creates an S3Storage from an "s3://bucket/prefix" DataPath.
+func NewS3Storage(cfg *config.Config) *S3Storage {
+	bucket, prefix := parseBucketURL(cfg.DataPath, "s3://")
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
+	if err != nil {
+		// As with GCSStorage, defer the error to the first real
+		// operation rather than failing construction.
+		fmt.Fprintf(os.Stderr, "Warning: failed to load AWS config: %v\n", err)
+	}
+
+	return &S3Storage{config: cfg, bucket: bucket, prefix: prefix, client: s3.NewFromConfig(awsCfg)}
+}
+
+func (s *S3Storage) atomKey(atomID string) string {
+	return path.Join(s.prefix, "atoms", atomID+".yaml")
+}
+
+func (s *S3Storage) indexKey() string {
+	return path.Join(s.prefix, "index.yaml")
+}
+
+// Save uploads atom as a YAML object.
+func (s *S3Storage) Save(atom *models.Atom) (string, error) {
+	data, err := yaml.Marshal(atom)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal atom to YAML: %w", err)
+	}
+
+	key := s.atomKey(atom.ID)
+	_, err = s.client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to put atom object: %w", err)
+	}
+
+	return fmt.Sprintf("s3://%s/%s", s.bucket, key), nil
+}
+
+// Load downloads and unmarshals an atom, returning (nil, nil) if it
+// doesn't exist.
+func (s *S3Storage) Load(atomID string) (*models.Atom, error) {
+	out, err := s.client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.atomKey(atomID)),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get atom object: %w", err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read atom object: %w", err)
+	}
+
+	var atom models.Atom
+	if err := yaml.Unmarshal(data, &atom); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal atom: %w", err)
+	}
+	if err := verifyContentHash(&atom); err != nil {
+		return nil, err
+	}
+	return &atom, nil
+}
+
+// Delete removes an atom object, reporting whether it existed.
+func (s *S3Storage) Delete(atomID string) (bool, error) {
+	if !s.Exists(atomID) {
+		return false, nil
+	}
+	_, err := s.client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.atomKey(atomID)),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete atom object: %w", err)
+	}
+	return true, nil
+}
+
+// Exists reports whether an atom object is present.
+func (s *S3Storage) Exists(atomID string) bool {
+	_, err := s.client.HeadObject(context.Background(), &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.atomKey(atomID)),
+	})
+	return err == nil
+}
+
+// ListAllIDs lists every atom object's ID under the atoms/ prefix.
+func (s *S3Storage) ListAllIDs() ([]string, error) {
+	atomsPrefix := path.Join(s.prefix, "atoms") + "/"
+
+	var ids []string
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(atomsPrefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list atom objects: %w", err)
+		}
+		for _, obj := range page.Contents {
+			name := strings.TrimPrefix(aws.ToString(obj.Key), atomsPrefix)
+			if strings.HasSuffix(name, ".yaml") {
+				ids = append(ids, strings.TrimSuffix(name, ".yaml"))
+			}
+		}
+	}
+	return ids, nil
+}
+
+// Walk streams every atom through fn in listing order.
+func (s *S3Storage) Walk(fn WalkFunc) error {
+	ids, err := s.ListAllIDs()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		atom, err := s.Load(id)
+		if err != nil {
+			return fmt.Errorf("failed to load atom %s: %w", id, err)
+		}
+		if atom == nil {
+			continue
+		}
+		if err := fn(atom); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IndexCachePath returns the local path the bucket's index.yaml is mirrored
+// to between polls.
+func (s *S3Storage) IndexCachePath() string {
+	return s.config.IndexPath()
+}
+
+// WatchIndex polls the bucket's index.yaml object on the given interval,
+// refreshing IndexCachePath and invoking onChange whenever the object's
+// ETag differs from the last sync. It returns a stop function that ends
+// the polling goroutine.
+func (s *S3Storage) WatchIndex(interval time.Duration, onChange func()) (stop func()) {
+	done := make(chan struct{})
+
+	sync := func() {
+		head, err := s.client.HeadObject(context.Background(), &s3.HeadObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(s.indexKey()),
+		})
+		if err != nil {
+			return
+		}
+		etag := aws.ToString(head.ETag)
+
+		s.mu.Lock()
+		changed := etag != s.lastETag
+		s.mu.Unlock()
+		if !changed {
+			return
+		}
+
+		out, err := s.client.GetObject(context.Background(), &s3.GetObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(s.indexKey()),
+		})
+		if err != nil {
+			return
+		}
+		defer out.Body.Close()
+
+		data, err := io.ReadAll(out.Body)
+		if err != nil {
+			return
+		}
+
+		if err := s.config.EnsureDirs(); err != nil {
+			return
+		}
+		if err := os.WriteFile(s.IndexCachePath(), data, 0644); err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.lastETag = etag
+		s.mu.Unlock()
+
+		if onChange != nil {
+			onChange()
+		}
+	}
+
+	// Prime the cache synchronously so the first GetIndex call after
+	// startup doesn't race the first poll tick.
+	sync()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				sync()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
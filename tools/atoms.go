@@ -1,10 +1,21 @@
 package tools
 
 import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/sephriot/knowledge-mcp/config"
 	"github.com/sephriot/knowledge-mcp/models"
 	"github.com/sephriot/knowledge-mcp/storage"
@@ -14,17 +25,17 @@ import (
 type AtomTools struct {
 	config       *config.Config
 	indexManager *storage.IndexManager
-	atomStorage  *storage.AtomStorage
+	atomStorage  storage.AtomStorage
 }
 
 // NewAtomTools creates a new atom tools instance.
-func NewAtomTools(cfg *config.Config) *AtomTools {
+func NewAtomTools(cfg *config.Config, indexManager *storage.IndexManager) *AtomTools {
 	if cfg == nil {
 		cfg = config.GetConfig()
 	}
 	return &AtomTools{
 		config:       cfg,
-		indexManager: storage.NewIndexManager(cfg),
+		indexManager: indexManager,
 		atomStorage:  storage.NewAtomStorage(cfg),
 	}
 }
@@ -202,42 +213,538 @@ func (t *AtomTools) GetNextID() (map[string]any, error) {
 	}, nil
 }
 
-// ExportAll exports all knowledge as a single structure.
-func (t *AtomTools) ExportAll(format string) (map[string]any, error) {
-	if format != "json" {
+// ExportAll exports all knowledge, in one of several formats:
+//   - "json": a single JSON structure (default), returned inline.
+//   - "jsonl": one JSON atom per line, for streaming into downstream
+//     indexers, returned inline.
+//   - "yaml": the same structure as "json", rendered as YAML, returned
+//     inline.
+//   - "markdown": one Markdown file per atom, with YAML front-matter for
+//     title/type/tags and a body built from summary/details/pitfalls,
+//     written under the outputPath directory - suitable for committing to
+//     a docs site.
+//   - "tgz": a gzipped tar of the current on-disk atom files plus the
+//     index file, written to outputPath, for a portable point-in-time
+//     snapshot.
+//
+// Markdown and tgz write to disk and return the path; the other formats
+// return their content inline. outputPath is ignored by the inline formats.
+func (t *AtomTools) ExportAll(format, outputPath string) (map[string]any, error) {
+	index, err := t.indexManager.GetIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	atoms := make([]*models.Atom, 0, len(index.Atoms))
+	for _, entry := range index.Atoms {
+		atom, err := t.atomStorage.Load(entry.ID)
+		if err != nil {
+			continue
+		}
+		if atom != nil {
+			atoms = append(atoms, atom)
+		}
+	}
+
+	switch format {
+	case "json":
+		return t.exportJSON(atoms), nil
+	case "jsonl":
+		return t.exportJSONL(atoms)
+	case "yaml":
+		return t.exportYAML(atoms)
+	case "markdown":
+		return t.exportMarkdown(atoms, outputPath)
+	case "tgz":
+		return t.exportTarball(outputPath)
+	default:
 		return map[string]any{
 			"error": fmt.Sprintf("Unsupported format: %s", format),
 		}, nil
 	}
+}
+
+// exportJSON builds the single-structure export shared by the "json" and
+// "yaml" formats.
+func (t *AtomTools) exportJSON(atoms []*models.Atom) map[string]any {
+	atomMaps := make([]map[string]any, 0, len(atoms))
+	for _, atom := range atoms {
+		atomMaps = append(atomMaps, atomToMap(atom))
+	}
+
+	return map[string]any{
+		"version":     1,
+		"exported_at": time.Now().Format("2006-01-02"),
+		"count":       len(atomMaps),
+		"atoms":       atomMaps,
+	}
+}
+
+// exportJSONL renders one JSON atom per line, suitable for streaming into
+// downstream indexers without parsing the whole export at once.
+func (t *AtomTools) exportJSONL(atoms []*models.Atom) (map[string]any, error) {
+	var buf strings.Builder
+	for _, atom := range atoms {
+		data, err := json.Marshal(atomToMap(atom))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal atom %s: %w", atom.ID, err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	return map[string]any{
+		"format":  "jsonl",
+		"count":   len(atoms),
+		"content": buf.String(),
+	}, nil
+}
+
+// exportYAML renders the same structure as exportJSON, as YAML.
+func (t *AtomTools) exportYAML(atoms []*models.Atom) (map[string]any, error) {
+	data, err := yaml.Marshal(t.exportJSON(atoms))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal export to YAML: %w", err)
+	}
+
+	return map[string]any{
+		"format":  "yaml",
+		"count":   len(atoms),
+		"content": string(data),
+	}, nil
+}
+
+// markdownFrontMatter is the YAML front-matter block written at the top of
+// each exported atom's Markdown file.
+type markdownFrontMatter struct {
+	Title string   `yaml:"title"`
+	Type  string   `yaml:"type"`
+	Tags  []string `yaml:"tags"`
+}
+
+// exportMarkdown writes one .md file per atom under outputPath, with YAML
+// front-matter for title/type/tags and a body built from
+// summary/details/pitfalls.
+func (t *AtomTools) exportMarkdown(atoms []*models.Atom, outputPath string) (map[string]any, error) {
+	if outputPath == "" {
+		return nil, fmt.Errorf("output_path is required for markdown export")
+	}
+	if err := os.MkdirAll(outputPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	for _, atom := range atoms {
+		frontMatter, err := yaml.Marshal(markdownFrontMatter{
+			Title: atom.Title,
+			Type:  string(atom.Type),
+			Tags:  atom.Tags,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal front-matter for %s: %w", atom.ID, err)
+		}
+
+		var body strings.Builder
+		body.WriteString("---\n")
+		body.Write(frontMatter)
+		body.WriteString("---\n\n")
+		fmt.Fprintf(&body, "# %s\n\n", atom.Title)
+		if atom.Content.Summary != "" {
+			fmt.Fprintf(&body, "## Summary\n\n%s\n\n", atom.Content.Summary)
+		}
+		if atom.Content.Details != "" {
+			fmt.Fprintf(&body, "## Details\n\n%s\n\n", atom.Content.Details)
+		}
+		if len(atom.Content.Pitfalls) > 0 {
+			body.WriteString("## Pitfalls\n\n")
+			for _, pitfall := range atom.Content.Pitfalls {
+				fmt.Fprintf(&body, "- %s\n", pitfall)
+			}
+			body.WriteString("\n")
+		}
+
+		path := filepath.Join(outputPath, atom.ID+".md")
+		if err := os.WriteFile(path, []byte(body.String()), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	return map[string]any{
+		"success": true,
+		"format":  "markdown",
+		"path":    outputPath,
+		"count":   len(atoms),
+	}, nil
+}
+
+// exportTarball writes a gzipped tar of the current on-disk atom files plus
+// the index file to outputPath, giving a portable point-in-time snapshot.
+func (t *AtomTools) exportTarball(outputPath string) (map[string]any, error) {
+	if outputPath == "" {
+		return nil, fmt.Errorf("output_path is required for tgz export")
+	}
+	if dir := filepath.Dir(outputPath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", outputPath, err)
+	}
+	defer f.Close()
 
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	indexPath := t.config.IndexPath()
+	if _, err := os.Stat(indexPath); os.IsNotExist(err) {
+		indexPath = t.config.IndexPathJSON()
+	}
+	if _, err := os.Stat(indexPath); err == nil {
+		if err := addFileToTar(tw, indexPath, filepath.Base(indexPath)); err != nil {
+			return nil, err
+		}
+	}
+
+	atomsPath := t.config.AtomsPath()
+	entries, err := os.ReadDir(atomsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read atoms directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := addFileToTar(tw, filepath.Join(atomsPath, entry.Name()), filepath.Join("atoms", entry.Name())); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize tar archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize gzip stream: %w", err)
+	}
+
+	return map[string]any{
+		"success": true,
+		"format":  "tgz",
+		"path":    outputPath,
+	}, nil
+}
+
+// addFileToTar writes the file at srcPath into tw under archiveName.
+func addFileToTar(tw *tar.Writer, srcPath, archiveName string) error {
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", srcPath, err)
+	}
+	return addBytesToTar(tw, archiveName, data)
+}
+
+// addBytesToTar writes data into tw under archiveName.
+func addBytesToTar(tw *tar.Writer, archiveName string, data []byte) error {
+	hdr := &tar.Header{
+		Name: archiveName,
+		Mode: 0644,
+		Size: int64(len(data)),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write tar header for %s: %w", archiveName, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write tar content for %s: %w", archiveName, err)
+	}
+	return nil
+}
+
+// archiveManifest describes the atoms packaged into an archive tgz, so
+// RestoreArchive can verify each atom file against its recorded hash.
+type archiveManifest struct {
+	SchemaVersion int                   `json:"schema_version"`
+	CreatedAt     string                `json:"created_at"`
+	Filter        archiveFilter         `json:"filter"`
+	Atoms         []archiveManifestAtom `json:"atoms"`
+}
+
+// archiveFilter records the type/tag/status filters an archive was built
+// with, for reference when inspecting an archive later.
+type archiveFilter struct {
+	Types  []string `json:"types,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	Status *string  `json:"status,omitempty"`
+}
+
+// archiveManifestAtom is one atom's entry in an archive manifest.
+type archiveManifestAtom struct {
+	ID     string `json:"id"`
+	SHA256 string `json:"sha256"`
+}
+
+// ArchiveAtoms packages the atoms matching the given type/tag/status
+// filters, plus a manifest (schema version, created_at, filter used, atom
+// IDs and their content hashes), into
+// "<data-path>/archives/<name>-<timestamp>.tgz".
+func (t *AtomTools) ArchiveAtoms(name string, types, tags []string, status *string) (map[string]any, error) {
 	index, err := t.indexManager.GetIndex()
 	if err != nil {
 		return nil, err
 	}
 
-	atoms := make([]map[string]any, 0)
+	typeSet := make(map[models.AtomType]bool)
+	for _, typ := range types {
+		typeSet[models.AtomType(typ)] = true
+	}
 
+	var matched []*models.Atom
 	for _, entry := range index.Atoms {
+		if len(typeSet) > 0 && !typeSet[entry.Type] {
+			continue
+		}
+		if status != nil && string(entry.Status) != *status {
+			continue
+		}
+		if len(tags) > 0 && !hasAnyTag(entry.Tags, tags) {
+			continue
+		}
+
 		atom, err := t.atomStorage.Load(entry.ID)
+		if err != nil || atom == nil {
+			continue
+		}
+		matched = append(matched, atom)
+	}
+
+	archivesDir := t.config.ArchivesPath()
+	if err := os.MkdirAll(archivesDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create archives directory: %w", err)
+	}
+	timestamp := time.Now().UTC().Format("20060102T150405Z")
+	archivePath := filepath.Join(archivesDir, fmt.Sprintf("%s-%s.tgz", name, timestamp))
+
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", archivePath, err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	manifest := archiveManifest{
+		SchemaVersion: 1,
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+		Filter:        archiveFilter{Types: types, Tags: tags, Status: status},
+	}
+
+	for _, atom := range matched {
+		data, err := yaml.Marshal(atom)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal atom %s: %w", atom.ID, err)
+		}
+		sum := sha256.Sum256(data)
+		manifest.Atoms = append(manifest.Atoms, archiveManifestAtom{
+			ID:     atom.ID,
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+
+		if err := addBytesToTar(tw, filepath.Join("atoms", atom.ID+".yaml"), data); err != nil {
+			return nil, err
+		}
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := addBytesToTar(tw, "manifest.json", manifestData); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize tar archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize gzip stream: %w", err)
+	}
+
+	return map[string]any{
+		"success": true,
+		"path":    archivePath,
+		"count":   len(matched),
+	}, nil
+}
+
+// hasAnyTag reports whether entryTags contains any of wantTags.
+func hasAnyTag(entryTags, wantTags []string) bool {
+	for _, want := range wantTags {
+		for _, tag := range entryTags {
+			if tag == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readArchive opens the tgz at path and extracts its manifest (if present)
+// and the raw bytes of every "atoms/*.yaml" entry, keyed by archive name.
+func readArchive(path string) (map[string][]byte, *archiveManifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read gzip stream: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	atomFiles := make(map[string][]byte)
+	var manifest *archiveManifest
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read tar entry: %w", err)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", hdr.Name, err)
+		}
+
+		switch {
+		case hdr.Name == "manifest.json":
+			var m archiveManifest
+			if err := json.Unmarshal(data, &m); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse manifest: %w", err)
+			}
+			manifest = &m
+		case strings.HasPrefix(hdr.Name, "atoms/") && strings.HasSuffix(hdr.Name, ".yaml"):
+			atomFiles[hdr.Name] = data
+		}
+	}
+
+	return atomFiles, manifest, nil
+}
+
+// RestoreArchive reads a manifest produced by ArchiveAtoms and restores its
+// atoms. mode controls how conflicts with existing atom IDs are handled:
+//   - "skip_existing": atoms whose ID already exists on disk are left alone.
+//   - "overwrite": atoms whose ID already exists on disk are replaced.
+//   - "rename": every atom is given a fresh K-ID via GetNextID on a staged
+//     copy of the index (so each allocation sees the previous one),
+//     and links[].id inside the imported atoms are rewritten to point at
+//     the new IDs, so restoring into a knowledge base that already has
+//     K-IDs of its own doesn't collide or silently merge unrelated atoms.
+//
+// If the archive has a manifest, atoms whose content hash no longer matches
+// it are treated as corrupted and skipped rather than aborting the whole
+// restore. The index is rebuilt from the atom files once restore finishes.
+func (t *AtomTools) RestoreArchive(path, mode string) (map[string]any, error) {
+	if mode == "" {
+		mode = "skip_existing"
+	}
+	if mode != "skip_existing" && mode != "overwrite" && mode != "rename" {
+		return nil, fmt.Errorf("invalid restore mode: %s (want skip_existing, overwrite, or rename)", mode)
+	}
+
+	atomFiles, manifest, err := readArchive(path)
+	if err != nil {
+		return nil, err
+	}
+
+	expectedHash := make(map[string]string)
+	if manifest != nil {
+		for _, a := range manifest.Atoms {
+			expectedHash[a.ID] = a.SHA256
+		}
+	}
+
+	index, err := t.indexManager.GetIndex()
+	if err != nil {
+		return nil, err
+	}
+	staged := index.Clone()
+
+	type pendingAtom struct {
+		atom *models.Atom
+	}
+	pending := make([]pendingAtom, 0, len(atomFiles))
+	idMap := make(map[string]string)
+	corrupted := 0
+
+	for _, data := range atomFiles {
+		var atom models.Atom
+		if err := yaml.Unmarshal(data, &atom); err != nil {
 			continue
 		}
-		if atom != nil {
-			atoms = append(atoms, atomToMap(atom))
+
+		sum := sha256.Sum256(data)
+		if expected, ok := expectedHash[atom.ID]; ok && expected != hex.EncodeToString(sum[:]) {
+			corrupted++
+			continue
+		}
+
+		if mode == "rename" {
+			newID := staged.GetNextID()
+			staged.AddOrUpdate(&models.IndexEntry{ID: newID})
+			idMap[atom.ID] = newID
 		}
+
+		pending = append(pending, pendingAtom{atom: &atom})
+	}
+
+	restored, skipped := 0, 0
+	for _, p := range pending {
+		atom := p.atom
+
+		if newID, ok := idMap[atom.ID]; ok {
+			atom.ID = newID
+			for i, link := range atom.Links {
+				if mappedID, ok := idMap[link.ID]; ok {
+					atom.Links[i].ID = mappedID
+				}
+			}
+		}
+
+		if mode == "skip_existing" && t.atomStorage.Exists(atom.ID) {
+			skipped++
+			continue
+		}
+
+		if _, err := t.atomStorage.Save(atom); err != nil {
+			return nil, fmt.Errorf("failed to save atom %s: %w", atom.ID, err)
+		}
+		restored++
+	}
+
+	rebuilt, err := t.indexManager.RebuildFromAtoms(t.atomStorage)
+	if err != nil {
+		return nil, err
 	}
 
 	return map[string]any{
-		"version":     1,
-		"exported_at": time.Now().Format("2006-01-02"),
-		"count":       len(atoms),
-		"atoms":       atoms,
+		"success":   true,
+		"restored":  restored,
+		"skipped":   skipped,
+		"corrupted": corrupted,
+		"total":     len(rebuilt.Atoms),
 	}, nil
 }
 
 // RebuildIndex rebuilds index.json from atom files.
 func (t *AtomTools) RebuildIndex() (map[string]any, error) {
-	index, err := t.indexManager.RebuildFromAtoms(t.config.AtomsPath())
+	index, err := t.indexManager.RebuildFromAtoms(t.atomStorage)
 	if err != nil {
 		return nil, err
 	}
@@ -249,6 +756,162 @@ func (t *AtomTools) RebuildIndex() (map[string]any, error) {
 	}, nil
 }
 
+// relatedAtomsNodeBudget caps how many nodes RelatedAtoms will visit, so a
+// densely-linked knowledge base can't turn a traversal into a full scan.
+const relatedAtomsNodeBudget = 200
+
+// relatedAtomsDefaultDepth is used when RelatedAtoms is called with
+// maxDepth <= 0.
+const relatedAtomsDefaultDepth = 2
+
+// RelatedAtomNode is one atom visited by RelatedAtoms' traversal.
+type RelatedAtomNode struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Type  string `json:"type"`
+	Depth int    `json:"depth"`
+}
+
+// RelatedAtomEdge is one edge in RelatedAtoms' traversal, in its original
+// direction regardless of which side the BFS reached it from.
+type RelatedAtomEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Rel  string `json:"rel"`
+}
+
+// RelatedAtomsResult is the graph returned by RelatedAtoms: every atom
+// visited plus the edges between them, suitable for a client to render.
+type RelatedAtomsResult struct {
+	Nodes []RelatedAtomNode `json:"nodes"`
+	Edges []RelatedAtomEdge `json:"edges"`
+}
+
+// relatedAtomsEdge is a directed edge discovered while scanning atoms,
+// before it's known whether the traversal will actually reach both ends.
+type relatedAtomsEdge struct {
+	from string
+	to   string
+	rel  string
+}
+
+// RelatedAtoms does a bounded BFS from id over outgoing atom.Links (plus a
+// synthetic "supersedes" edge for every entry in atom.Supersedes), following
+// only rels in rels (or all rels if rels is empty). A backlink map is built
+// up front so relationships are walkable in both directions - e.g. a
+// see_also from A to B also lets a traversal rooted at B reach A. Traversal
+// stops at maxDepth hops (default 2) or relatedAtomsNodeBudget visited
+// nodes, whichever comes first; cycles are handled by never revisiting a
+// node once seen.
+func (t *AtomTools) RelatedAtoms(id string, maxDepth int, rels []string) (*RelatedAtomsResult, error) {
+	if maxDepth <= 0 {
+		maxDepth = relatedAtomsDefaultDepth
+	}
+
+	relSet := make(map[string]bool, len(rels))
+	for _, r := range rels {
+		relSet[r] = true
+	}
+	relAllowed := func(rel string) bool {
+		return len(relSet) == 0 || relSet[rel]
+	}
+
+	index, err := t.indexManager.GetIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	atomsByID := make(map[string]*models.Atom, len(index.Atoms))
+	adjacency := make(map[string][]relatedAtomsEdge)
+	var allEdges []relatedAtomsEdge
+
+	addEdge := func(from, to, rel string) {
+		if !relAllowed(rel) {
+			return
+		}
+		e := relatedAtomsEdge{from: from, to: to, rel: rel}
+		allEdges = append(allEdges, e)
+		adjacency[from] = append(adjacency[from], e)
+		adjacency[to] = append(adjacency[to], e)
+	}
+
+	for _, entry := range index.Atoms {
+		atom, err := t.atomStorage.Load(entry.ID)
+		if err != nil || atom == nil {
+			continue
+		}
+		atomsByID[atom.ID] = atom
+
+		for _, link := range atom.Links {
+			addEdge(atom.ID, link.ID, string(link.Rel))
+		}
+		for _, supersededID := range atom.Supersedes {
+			addEdge(atom.ID, supersededID, "supersedes")
+		}
+	}
+
+	if _, ok := atomsByID[id]; !ok {
+		return nil, fmt.Errorf("atom %s not found", id)
+	}
+
+	depth := map[string]int{id: 0}
+	order := []string{id}
+	queue := []string{id}
+
+	for len(queue) > 0 && len(order) < relatedAtomsNodeBudget {
+		current := queue[0]
+		queue = queue[1:]
+		if depth[current] >= maxDepth {
+			continue
+		}
+
+		for _, e := range adjacency[current] {
+			neighbor := e.to
+			if neighbor == current {
+				neighbor = e.from
+			}
+			if neighbor == current {
+				continue // self-link
+			}
+			if _, seen := depth[neighbor]; seen {
+				continue
+			}
+
+			depth[neighbor] = depth[current] + 1
+			order = append(order, neighbor)
+			queue = append(queue, neighbor)
+			if len(order) >= relatedAtomsNodeBudget {
+				break
+			}
+		}
+	}
+
+	nodes := make([]RelatedAtomNode, 0, len(order))
+	visited := make(map[string]bool, len(order))
+	for _, atomID := range order {
+		visited[atomID] = true
+		atom, ok := atomsByID[atomID]
+		if !ok {
+			continue
+		}
+		nodes = append(nodes, RelatedAtomNode{
+			ID:    atom.ID,
+			Title: atom.Title,
+			Type:  string(atom.Type),
+			Depth: depth[atomID],
+		})
+	}
+
+	edges := make([]RelatedAtomEdge, 0)
+	for _, e := range allEdges {
+		if visited[e.from] && visited[e.to] {
+			edges = append(edges, RelatedAtomEdge{From: e.from, To: e.to, Rel: e.rel})
+		}
+	}
+
+	return &RelatedAtomsResult{Nodes: nodes, Edges: edges}, nil
+}
+
 // GetSummary gets summary of knowledge grouped by type, tag, or language.
 func (t *AtomTools) GetSummary(groupBy string) (map[string]any, error) {
 	index, err := t.indexManager.GetIndex()
@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"github.com/sephriot/knowledge-mcp/config"
+	"github.com/sephriot/knowledge-mcp/models"
+	"github.com/sephriot/knowledge-mcp/storage"
+)
+
+// AttachmentTools provides tools for managing file attachments on atoms.
+// AttachmentTools works through storage.FilesystemStorage only: attachment
+// bytes and metadata live beside the atom files on local disk. GCSStorage,
+// S3Storage, and MemoryStorage don't implement the attachment API, and with
+// such a DataPath the atom isn't found on local disk, so these tools return
+// an "atom not found" error.
+type AttachmentTools struct {
+	config       *config.Config
+	indexManager *storage.IndexManager
+	atomStorage  *storage.FilesystemStorage
+}
+
+// NewAttachmentTools creates a new attachment tools instance.
+func NewAttachmentTools(cfg *config.Config, indexManager *storage.IndexManager) *AttachmentTools {
+	if cfg == nil {
+		cfg = config.GetConfig()
+	}
+	return &AttachmentTools{
+		config:       cfg,
+		indexManager: indexManager,
+		atomStorage:  storage.NewFilesystemStorage(cfg),
+	}
+}
+
+// reindexAttachmentCount refreshes the atom's index entry so AttachmentCount
+// stays accurate after an attachment is added or removed.
+func (t *AttachmentTools) reindexAttachmentCount(atomID string) error {
+	atom, err := t.atomStorage.Load(atomID)
+	if err != nil {
+		return err
+	}
+	if atom == nil {
+		return nil
+	}
+	return t.indexManager.AddOrUpdate(models.NewIndexEntryFromAtom(atom))
+}
+
+func attachmentToMap(a *models.Attachment) map[string]any {
+	return map[string]any{
+		"id":         a.ID,
+		"name":       a.Name,
+		"mime_type":  a.MimeType,
+		"size":       a.Size,
+		"sha256":     a.SHA256,
+		"created_at": a.CreatedAt,
+	}
+}
+
+// AttachFile creates a new attachment on an atom from base64-encoded bytes.
+func (t *AttachmentTools) AttachFile(atomID, name, mimeType, contentBase64 string) (map[string]any, error) {
+	data, err := base64.StdEncoding.DecodeString(contentBase64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 content: %w", err)
+	}
+
+	w, attachment, err := t.atomStorage.CreateAttachment(atomID, name, mimeType)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("failed to write attachment: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := t.reindexAttachmentCount(atomID); err != nil {
+		return nil, err
+	}
+
+	return attachmentToMap(attachment), nil
+}
+
+// AppendFile appends base64-encoded bytes to an existing attachment.
+func (t *AttachmentTools) AppendFile(atomID, attachmentID, contentBase64 string) (map[string]any, error) {
+	data, err := base64.StdEncoding.DecodeString(contentBase64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 content: %w", err)
+	}
+
+	w, err := t.atomStorage.AppendAttachment(atomID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("failed to append to attachment: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	attachments, err := t.atomStorage.ListAttachments(atomID)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range attachments {
+		if a.ID == attachmentID {
+			return attachmentToMap(&a), nil
+		}
+	}
+	return nil, fmt.Errorf("attachment %s not found after append", attachmentID)
+}
+
+// GetFile returns an attachment's metadata and base64-encoded content.
+func (t *AttachmentTools) GetFile(atomID, attachmentID string) (map[string]any, error) {
+	rc, attachment, err := t.atomStorage.OpenAttachment(atomID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+
+	result := attachmentToMap(attachment)
+	result["content_base64"] = base64.StdEncoding.EncodeToString(data)
+	return result, nil
+}
+
+// ListFiles lists attachment metadata for an atom.
+func (t *AttachmentTools) ListFiles(atomID string) (map[string]any, error) {
+	attachments, err := t.atomStorage.ListAttachments(atomID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]map[string]any, 0, len(attachments))
+	for _, a := range attachments {
+		items = append(items, attachmentToMap(&a))
+	}
+
+	return map[string]any{
+		"atom_id": atomID,
+		"count":   len(items),
+		"files":   items,
+	}, nil
+}
+
+// DeleteFile permanently removes an attachment from an atom.
+func (t *AttachmentTools) DeleteFile(atomID, attachmentID string) (map[string]any, error) {
+	ok, err := t.atomStorage.DeleteAttachment(atomID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return map[string]any{
+			"success": false,
+			"error":   fmt.Sprintf("Attachment %s not found on atom %s", attachmentID, atomID),
+		}, nil
+	}
+
+	if err := t.reindexAttachmentCount(atomID); err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"success": true,
+		"message": fmt.Sprintf("Attachment %s deleted from atom %s", attachmentID, atomID),
+	}, nil
+}
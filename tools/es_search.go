@@ -0,0 +1,372 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+
+	"github.com/sephriot/knowledge-mcp/config"
+	"github.com/sephriot/knowledge-mcp/models"
+	"github.com/sephriot/knowledge-mcp/storage"
+)
+
+// ESSearchEngine is a Searcher backed by Elasticsearch/OpenSearch, for
+// knowledge bases too large for the in-process BM25 index to handle
+// comfortably. It falls back to the local engine whenever the cluster is
+// unreachable, so a flaky ES endpoint degrades search quality rather than
+// breaking it.
+type ESSearchEngine struct {
+	config       *config.Config
+	indexManager *storage.IndexManager
+	atomStorage  storage.AtomStorage
+	client       *elasticsearch.Client
+	index        string
+	fallback     *SearchEngine
+}
+
+// esAtomDoc mirrors models.Atom's searchable fields for indexing.
+type esAtomDoc struct {
+	ID         string   `json:"id"`
+	Title      string   `json:"title"`
+	Type       string   `json:"type"`
+	Status     string   `json:"status"`
+	Confidence string   `json:"confidence"`
+	Language   *string  `json:"language,omitempty"`
+	Tags       []string `json:"tags"`
+	Summary    string   `json:"summary"`
+	Details    string   `json:"details"`
+	UpdatedAt  string   `json:"updated_at"`
+}
+
+func newESAtomDoc(atom *models.Atom) esAtomDoc {
+	return esAtomDoc{
+		ID:         atom.ID,
+		Title:      atom.Title,
+		Type:       string(atom.Type),
+		Status:     string(atom.Status),
+		Confidence: string(atom.Confidence),
+		Language:   atom.Language,
+		Tags:       atom.Tags,
+		Summary:    atom.Content.Summary,
+		Details:    atom.Content.Details,
+		UpdatedAt:  atom.UpdatedAt,
+	}
+}
+
+// NewESSearchEngine connects to the configured Elasticsearch/OpenSearch
+// endpoint, ensures the index mapping exists, and performs a health check.
+// If the cluster cannot be reached, it returns an error so NewSearcher can
+// fall back to the local engine.
+func NewESSearchEngine(cfg *config.Config, indexManager *storage.IndexManager, fallback *SearchEngine) (*ESSearchEngine, error) {
+	client, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: []string{cfg.ElasticsearchURL},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create elasticsearch client: %w", err)
+	}
+
+	e := &ESSearchEngine{
+		config:       cfg,
+		indexManager: indexManager,
+		atomStorage:  storage.NewAtomStorage(cfg),
+		client:       client,
+		index:        cfg.ElasticsearchIndex,
+		fallback:     fallback,
+	}
+
+	if err := e.healthCheck(); err != nil {
+		return nil, err
+	}
+	if err := e.ensureMapping(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (e *ESSearchEngine) healthCheck() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := e.client.Info(e.client.Info.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("elasticsearch unreachable: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("elasticsearch health check failed: %s", res.Status())
+	}
+	return nil
+}
+
+// ensureMapping creates the index with a mapping that uses keyword types
+// for the enum/filter fields and text with a standard analyzer for the
+// searchable content.
+func (e *ESSearchEngine) ensureMapping() error {
+	mapping := `{
+		"mappings": {
+			"properties": {
+				"id":         {"type": "keyword"},
+				"title":      {"type": "text"},
+				"type":       {"type": "keyword"},
+				"status":     {"type": "keyword"},
+				"confidence": {"type": "keyword"},
+				"language":   {"type": "keyword"},
+				"tags":       {"type": "keyword"},
+				"summary":    {"type": "text"},
+				"details":    {"type": "text"},
+				"updated_at": {"type": "keyword"}
+			}
+		}
+	}`
+
+	res, err := e.client.Indices.Create(
+		e.index,
+		e.client.Indices.Create.WithBody(strings.NewReader(mapping)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create elasticsearch index: %w", err)
+	}
+	defer res.Body.Close()
+
+	// 400 with resource_already_exists_exception is fine - the index was
+	// created by a previous run.
+	if res.IsError() && res.StatusCode != 400 {
+		return fmt.Errorf("failed to create elasticsearch index: %s", res.Status())
+	}
+	return nil
+}
+
+// AddOrUpdate indexes (or re-indexes) a single atom document.
+func (e *ESSearchEngine) AddOrUpdate(atom *models.Atom) error {
+	doc := newESAtomDoc(atom)
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal atom document: %w", err)
+	}
+
+	req := esapi.IndexRequest{
+		Index:      e.index,
+		DocumentID: atom.ID,
+		Body:       bytes.NewReader(body),
+		Refresh:    "false",
+	}
+
+	res, err := req.Do(context.Background(), e.client)
+	if err != nil {
+		return fmt.Errorf("failed to index atom %s: %w", atom.ID, err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("failed to index atom %s: %s", atom.ID, res.Status())
+	}
+	return nil
+}
+
+// Remove deletes an atom document.
+func (e *ESSearchEngine) Remove(atomID string) error {
+	req := esapi.DeleteRequest{
+		Index:      e.index,
+		DocumentID: atomID,
+	}
+
+	res, err := req.Do(context.Background(), e.client)
+	if err != nil {
+		return fmt.Errorf("failed to delete atom %s: %w", atomID, err)
+	}
+	defer res.Body.Close()
+	if res.IsError() && res.StatusCode != 404 {
+		return fmt.Errorf("failed to delete atom %s: %s", atomID, res.Status())
+	}
+	return nil
+}
+
+// RebuildFromAtoms bulk-indexes every atom via the `_bulk` API.
+func (e *ESSearchEngine) RebuildFromAtoms() error {
+	ids, err := e.atomStorage.ListAllIDs()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, id := range ids {
+		atom, err := e.atomStorage.Load(id)
+		if err != nil || atom == nil {
+			continue
+		}
+		doc := newESAtomDoc(atom)
+
+		meta, _ := json.Marshal(map[string]any{
+			"index": map[string]any{"_index": e.index, "_id": atom.ID},
+		})
+		body, _ := json.Marshal(doc)
+
+		buf.Write(meta)
+		buf.WriteByte('\n')
+		buf.Write(body)
+		buf.WriteByte('\n')
+	}
+
+	if buf.Len() == 0 {
+		return nil
+	}
+
+	res, err := e.client.Bulk(bytes.NewReader(buf.Bytes()), e.client.Bulk.WithIndex(e.index))
+	if err != nil {
+		return fmt.Errorf("bulk index request failed: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("bulk index request failed: %s", res.Status())
+	}
+	return nil
+}
+
+// buildQuery translates the current filter set into a bool query with a
+// multi_match clause weighted title^3, tags^2, plus summary^1 and
+// details^0.5 when includeContent is set. The
+// query text is passed through untouched - ensureMapping indexes with the
+// standard analyzer (no stemming), so a pre-stemmed query term (e.g.
+// storage.Tokenize's "runn" for "running") would fail to match the
+// unstemmed index terms. Elasticsearch applies the same standard analyzer
+// to the query side of multi_match by default, keeping the two in sync.
+func (e *ESSearchEngine) buildQuery(queryTerms []string, types, tags []string, language, status *string, includeContent bool, fuzziness string) map[string]any {
+	fields := []string{"title^3", "tags^2"}
+	if includeContent {
+		fields = append(fields, "summary^1", "details^0.5")
+	}
+
+	must := []map[string]any{}
+	if len(queryTerms) > 0 {
+		multiMatch := map[string]any{
+			"query":  strings.Join(queryTerms, " "),
+			"fields": fields,
+		}
+		if fuzziness != "off" {
+			if fuzziness == "" {
+				fuzziness = "AUTO"
+			}
+			multiMatch["fuzziness"] = fuzziness
+		}
+		must = append(must, map[string]any{
+			"multi_match": multiMatch,
+		})
+	}
+
+	filter := []map[string]any{}
+	if len(types) > 0 {
+		filter = append(filter, map[string]any{"terms": map[string]any{"type": types}})
+	}
+	if len(tags) > 0 {
+		filter = append(filter, map[string]any{"terms": map[string]any{"tags": tags}})
+	}
+	if language != nil {
+		filter = append(filter, map[string]any{"term": map[string]any{"language": *language}})
+	}
+	if status != nil {
+		filter = append(filter, map[string]any{"term": map[string]any{"status": *status}})
+	}
+
+	return map[string]any{
+		"query": map[string]any{
+			"bool": map[string]any{
+				"must":   must,
+				"filter": filter,
+			},
+		},
+	}
+}
+
+type esSearchResponse struct {
+	Hits struct {
+		Hits []struct {
+			Score  float64   `json:"_score"`
+			Source esAtomDoc `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+func (e *ESSearchEngine) runQuery(queryTerms []string, types, tags []string, language, status *string, limit int, includeContent bool, fuzziness string) ([]SearchResult, error) {
+	query := e.buildQuery(queryTerms, types, tags, language, status, includeContent, fuzziness)
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal es query: %w", err)
+	}
+
+	res, err := e.client.Search(
+		e.client.Search.WithIndex(e.index),
+		e.client.Search.WithBody(bytes.NewReader(body)),
+		e.client.Search.WithSize(limit),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("elasticsearch query failed: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, fmt.Errorf("elasticsearch query failed: %s", res.Status())
+	}
+
+	var parsed esSearchResponse
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode elasticsearch response: %w", err)
+	}
+
+	results := make([]SearchResult, 0, len(parsed.Hits.Hits))
+	for _, hit := range parsed.Hits.Hits {
+		sr := SearchResult{
+			ID:         hit.Source.ID,
+			Title:      hit.Source.Title,
+			Type:       hit.Source.Type,
+			Status:     hit.Source.Status,
+			Confidence: hit.Source.Confidence,
+			Language:   hit.Source.Language,
+			Tags:       hit.Source.Tags,
+			UpdatedAt:  hit.Source.UpdatedAt,
+			Score:      int(hit.Score * 10),
+		}
+		if includeContent {
+			sr.Summary = hit.Source.Summary
+		} else if atom, err := e.atomStorage.Load(sr.ID); err == nil && atom != nil {
+			sr.Summary = atom.Content.Summary
+		}
+		results = append(results, sr)
+	}
+	return results, nil
+}
+
+// Search searches title/tags via Elasticsearch, falling back to the local
+// engine if the cluster errors or returns no hits (e.g. a cold or not-yet-
+// populated index).
+func (e *ESSearchEngine) Search(query []string, types []string, tags []string, language, status *string, limit int, fuzziness string) ([]SearchResult, error) {
+	results, err := e.runQuery(query, types, tags, language, status, limit, false, fuzziness)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: elasticsearch search failed (%v), falling back to local search\n", err)
+		return e.fallback.Search(query, types, tags, language, status, limit, fuzziness)
+	}
+	if len(results) == 0 {
+		return e.fallback.Search(query, types, tags, language, status, limit, fuzziness)
+	}
+	return results, nil
+}
+
+// SearchContent searches title/tags/summary/details via Elasticsearch,
+// falling back to the local engine if the cluster errors or returns no
+// hits (e.g. a cold or not-yet-populated index).
+func (e *ESSearchEngine) SearchContent(query []string, types []string, tags []string, language, status *string, limit int, fuzziness string) ([]SearchResult, error) {
+	results, err := e.runQuery(query, types, tags, language, status, limit, true, fuzziness)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: elasticsearch search failed (%v), falling back to local search\n", err)
+		return e.fallback.SearchContent(query, types, tags, language, status, limit, fuzziness)
+	}
+	if len(results) == 0 {
+		return e.fallback.SearchContent(query, types, tags, language, status, limit, fuzziness)
+	}
+	return results, nil
+}
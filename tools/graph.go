@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"github.com/sephriot/knowledge-mcp/graph"
+)
+
+// GraphTools exposes a graph.Graph as MCP tools.
+type GraphTools struct {
+	graph *graph.Graph
+}
+
+// NewGraphTools creates a new graph tools instance over g.
+func NewGraphTools(g *graph.Graph) *GraphTools {
+	return &GraphTools{graph: g}
+}
+
+// Neighbors finds atoms near id in the knowledge graph.
+func (t *GraphTools) Neighbors(id, rel string, depth int) ([]graph.NeighborNode, error) {
+	return t.graph.Neighbors(id, rel, depth)
+}
+
+// ShortestPath finds the shortest path between two atoms.
+func (t *GraphTools) ShortestPath(from, to string) ([]graph.PathStep, error) {
+	return t.graph.ShortestPath(from, to)
+}
+
+// DetectCycles finds depends_on cycles in the knowledge graph.
+func (t *GraphTools) DetectCycles() []graph.Cycle {
+	return t.graph.DetectCycles()
+}
+
+// FindContradictions finds atoms that contradict id, directly or
+// transitively via see_also.
+func (t *GraphTools) FindContradictions(id string, maxHops int) ([]graph.Contradiction, error) {
+	return t.graph.FindContradictions(id, maxHops)
+}
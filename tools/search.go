@@ -1,6 +1,8 @@
 package tools
 
 import (
+	"container/heap"
+	"strconv"
 	"strings"
 
 	"github.com/sephriot/knowledge-mcp/config"
@@ -12,10 +14,12 @@ import (
 type SearchEngine struct {
 	config       *config.Config
 	indexManager *storage.IndexManager
-	atomStorage  *storage.AtomStorage
+	atomStorage  storage.AtomStorage
+	fullText     *storage.FullTextIndex
 }
 
-// Priority values for ranking.
+// Priority values for ranking. These no longer drive the primary score -
+// BM25 does - but remain a tie-breaker between otherwise-equal results.
 var statusPriority = map[models.AtomStatus]int{
 	models.AtomStatusActive:     3,
 	models.AtomStatusDraft:      2,
@@ -37,6 +41,7 @@ func NewSearchEngine(cfg *config.Config, indexManager *storage.IndexManager) *Se
 		config:       cfg,
 		indexManager: indexManager,
 		atomStorage:  storage.NewAtomStorage(cfg),
+		fullText:     storage.NewFullTextIndex(cfg),
 	}
 }
 
@@ -54,138 +59,299 @@ type SearchResult struct {
 	Summary    string   `json:"summary,omitempty"`
 }
 
-// Search searches for knowledge atoms.
-func (e *SearchEngine) Search(query []string, types []string, tags []string, language, status *string, limit int) ([]SearchResult, error) {
+// ensureFullTextIndex makes sure the in-memory/persisted BM25 index matches
+// the current atom index, rebuilding it when stale. This keeps Search and
+// SearchContent's signatures unchanged - callers don't need to know the
+// index exists.
+func (e *SearchEngine) ensureFullTextIndex(index *models.Index) error {
+	if e.fullText.Version == "" {
+		if err := e.fullText.Load(); err != nil {
+			return err
+		}
+	}
+
+	if e.fullText.Version == index.UpdatedAt && e.fullText.DocCount == len(index.Atoms) {
+		return nil
+	}
+
+	ids := make([]string, 0, len(index.Atoms))
+	for _, entry := range index.Atoms {
+		ids = append(ids, entry.ID)
+	}
+
+	return e.fullText.RebuildFromAtoms(e.atomStorage, ids, index.UpdatedAt)
+}
+
+// scoredEntry pairs an index entry with its relevance score for ranking.
+type scoredEntry struct {
+	entry *models.IndexEntry
+	score float64
+}
+
+// resultHeap is a min-heap of scoredEntry, used to keep only the top-N
+// results while scanning the full candidate set in O(n log limit) instead
+// of sorting everything.
+type resultHeap []scoredEntry
+
+func (h resultHeap) Len() int { return len(h) }
+func (h resultHeap) Less(i, j int) bool {
+	if h[i].score != h[j].score {
+		return h[i].score < h[j].score
+	}
+	// Tie-breaker: lower combined status/confidence priority sorts first
+	// in the min-heap, so higher-priority entries survive a pop.
+	return tieBreak(h[i].entry) < tieBreak(h[j].entry)
+}
+func (h resultHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *resultHeap) Push(x any)        { *h = append(*h, x.(scoredEntry)) }
+func (h *resultHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+func tieBreak(entry *models.IndexEntry) int {
+	return statusPriority[entry.Status]*5 + confidencePriority[entry.Confidence]*3
+}
+
+// topN consumes scored entries and returns the top `limit` by score
+// (descending), using a bounded min-heap instead of sorting the whole set.
+func topN(entries []scoredEntry, limit int) []scoredEntry {
+	if limit <= 0 {
+		return nil
+	}
+
+	h := &resultHeap{}
+	heap.Init(h)
+	for _, se := range entries {
+		if h.Len() < limit {
+			heap.Push(h, se)
+			continue
+		}
+		if h.Len() > 0 && (*h)[0].score < se.score || ((*h)[0].score == se.score && tieBreak((*h)[0].entry) < tieBreak(se.entry)) {
+			heap.Pop(h)
+			heap.Push(h, se)
+		}
+	}
+
+	result := make([]scoredEntry, h.Len())
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i] = heap.Pop(h).(scoredEntry)
+	}
+	return result
+}
+
+// Search searches for knowledge atoms by title and tags using BM25.
+// fuzziness is "off" (exact only), "auto" (length-scaled edit budget,
+// the default), or a numeric string fixing the max edit distance for
+// every query token.
+func (e *SearchEngine) Search(query []string, types []string, tags []string, language, status *string, limit int, fuzziness string) ([]SearchResult, error) {
+	return e.search(query, types, tags, language, status, limit, false, fuzziness)
+}
+
+// SearchContent performs deep search including atom summary/details content.
+func (e *SearchEngine) SearchContent(query []string, types []string, tags []string, language, status *string, limit int, fuzziness string) ([]SearchResult, error) {
+	return e.search(query, types, tags, language, status, limit, true, fuzziness)
+}
+
+func (e *SearchEngine) search(query []string, types []string, tags []string, language, status *string, limit int, includeContent bool, fuzziness string) ([]SearchResult, error) {
 	index, err := e.indexManager.GetIndex()
 	if err != nil {
 		return nil, err
 	}
-
-	type scoredEntry struct {
-		entry *models.IndexEntry
-		score int
+	if err := e.ensureFullTextIndex(index); err != nil {
+		return nil, err
 	}
 
-	var results []scoredEntry
-	queryTokens := normalizeTokens(query)
+	queryTokens := storage.Tokenize(strings.Join(query, " "))
 
-	// Convert types to set for fast lookup
 	typeSet := make(map[models.AtomType]bool)
 	for _, t := range types {
 		typeSet[models.AtomType(t)] = true
 	}
 
-	for _, entry := range index.Atoms {
-		// Apply filters
+	matches := func(entry *models.IndexEntry) bool {
 		if len(typeSet) > 0 && !typeSet[entry.Type] {
-			continue
+			return false
 		}
 		if status != nil && string(entry.Status) != *status {
-			continue
+			return false
 		}
 		if language != nil && (entry.Language == nil || *entry.Language != *language) {
-			continue
+			return false
 		}
 		if len(tags) > 0 {
 			entryTagsLower := make(map[string]bool)
 			for _, t := range entry.Tags {
 				entryTagsLower[strings.ToLower(t)] = true
 			}
-			found := false
 			for _, tag := range tags {
 				if entryTagsLower[strings.ToLower(tag)] {
-					found = true
-					break
+					return true
 				}
 			}
-			if !found {
-				continue
+			return false
+		}
+		return true
+	}
+
+	var candidateEntries []*models.IndexEntry
+	if len(queryTokens) == 0 {
+		for _, entry := range index.Atoms {
+			if matches(entry) {
+				candidateEntries = append(candidateEntries, entry)
+			}
+		}
+	} else {
+		candidateIDs := e.fullText.CandidateAtoms(queryTokens)
+		fuzzyEnabled := fuzziness != "off"
+		fuzzyCandidates := make(map[string]bool)
+		if fuzzyEnabled {
+			for _, tok := range queryTokens {
+				for atomID := range e.fullText.FuzzyCandidates(tok) {
+					fuzzyCandidates[atomID] = true
+				}
 			}
 		}
 
-		// Calculate relevance score
-		score := e.calculateScore(entry, queryTokens)
-		if score > 0 {
-			results = append(results, scoredEntry{entry: entry, score: score})
+		for _, entry := range index.Atoms {
+			// When doing a content search, atoms whose only match is
+			// inside an attachment won't appear in candidateIDs (the
+			// BM25 index doesn't cover attachment text), so keep them
+			// as candidates too and let attachment scoring decide.
+			isCandidate := candidateIDs[entry.ID] || fuzzyCandidates[entry.ID] ||
+				(includeContent && entry.AttachmentCount > 0)
+			if !isCandidate {
+				continue
+			}
+			if matches(entry) {
+				candidateEntries = append(candidateEntries, entry)
+			}
 		}
 	}
 
-	// Sort by score (descending) - simple bubble sort for small datasets
-	for i := 0; i < len(results); i++ {
-		for j := i + 1; j < len(results); j++ {
-			if results[j].score > results[i].score {
-				results[i], results[j] = results[j], results[i]
+	var scored []scoredEntry
+	for _, entry := range candidateEntries {
+		var score float64
+		if len(queryTokens) == 0 {
+			score = 10 + float64(tieBreak(entry))
+		} else if includeContent {
+			score, _ = e.fullText.Score(entry.ID, queryTokens)
+			score += e.attachmentScore(entry, queryTokens)
+		} else {
+			score = e.titleTagScore(entry.ID, queryTokens)
+		}
+		if fuzziness != "off" && len(queryTokens) > 0 {
+			// fuzzyScore adds less than fuzzyScoreWeight per query token, so
+			// lifting every exact (BM25 or attachment) hit by that much keeps
+			// it ranked above any fuzzy-only match.
+			if score > 0 {
+				score += fuzzyScoreWeight * float64(len(queryTokens))
 			}
+			score += e.fuzzyScore(entry.ID, queryTokens, parseFuzziness(fuzziness))
+		}
+		if score > 0 {
+			scored = append(scored, scoredEntry{entry: entry, score: score})
 		}
 	}
 
-	// Limit results
-	if len(results) > limit {
-		results = results[:limit]
-	}
+	top := topN(scored, limit)
 
-	// Format results
-	formatted := make([]SearchResult, 0, len(results))
-	for _, r := range results {
-		formatted = append(formatted, e.formatResult(r.entry, r.score))
+	formatted := make([]SearchResult, 0, len(top))
+	for _, r := range top {
+		formatted = append(formatted, e.formatResult(r.entry, int(r.score)))
 	}
-
 	return formatted, nil
 }
 
-// normalizeTokens converts query tokens to lowercase for case-insensitive matching.
-func normalizeTokens(tokens []string) []string {
-	result := make([]string, 0, len(tokens))
-	for _, t := range tokens {
-		if t != "" {
-			result = append(result, strings.ToLower(t))
-		}
+// titleTagScore computes BM25 restricted to the title/tag fields, used by
+// the non-content Search so it doesn't surface matches that only occur in
+// summary/details.
+func (e *SearchEngine) titleTagScore(atomID string, queryTokens []string) float64 {
+	total, terms := e.fullText.Score(atomID, queryTokens)
+	_ = total
+	var restricted float64
+	for _, t := range terms {
+		restricted += t.Field[storage.FieldTitle] + t.Field[storage.FieldTag]
 	}
-	return result
+	return restricted
 }
 
-// calculateScore calculates relevance score for an entry.
-func (e *SearchEngine) calculateScore(entry *models.IndexEntry, queryTokens []string) int {
-	// Empty query returns all atoms with base score
-	if len(queryTokens) == 0 {
-		baseScore := 10
-		baseScore += statusPriority[entry.Status] * 5
-		baseScore += confidencePriority[entry.Confidence] * 3
-		return baseScore
+// attachmentScore folds text extracted from an atom's text/* and
+// application/pdf attachments into the content score: each query token adds
+// a flat bonus for every attachment whose extracted text contains it.
+const attachmentMatchBonus = 20.0
+
+func (e *SearchEngine) attachmentScore(entry *models.IndexEntry, queryTokens []string) float64 {
+	if entry.AttachmentCount == 0 {
+		return 0
 	}
 
-	matchScore := 0
-	titleLower := strings.ToLower(entry.Title)
+	// Attachments are only ever stored on the local filesystem, so
+	// backends that don't implement FilesystemStorage (GCS, S3) simply
+	// contribute no attachment score.
+	fsStorage, ok := e.atomStorage.(*storage.FilesystemStorage)
+	if !ok {
+		return 0
+	}
 
-	// Check each token - OR logic with cumulative scoring
-	for _, token := range queryTokens {
-		// Title match (highest weight per token)
-		if strings.Contains(titleLower, token) {
-			matchScore += 100
-			if strings.HasPrefix(titleLower, token) {
-				matchScore += 50
-			}
+	attachments, err := fsStorage.ListAttachments(entry.ID)
+	if err != nil {
+		return 0
+	}
+
+	var score float64
+	for _, att := range attachments {
+		rc, _, err := fsStorage.OpenAttachment(entry.ID, att.ID)
+		if err != nil {
+			continue
+		}
+		text, err := storage.ExtractAttachmentText(att.MimeType, rc)
+		rc.Close()
+		if err != nil || text == "" {
+			continue
 		}
 
-		// Tag match (per token)
-		for _, tag := range entry.Tags {
-			if strings.Contains(strings.ToLower(tag), token) {
-				matchScore += 30
-				break // Only count once per token
+		textLower := strings.ToLower(text)
+		for _, token := range queryTokens {
+			if strings.Contains(textLower, token) {
+				score += attachmentMatchBonus
 			}
 		}
 	}
+	return score
+}
 
-	// No match found - return 0
-	if matchScore == 0 {
-		return 0
+// fuzzyScoreWeight dampens fuzzy-match contributions relative to BM25 hits
+// so a typo match can surface a result but an exact match always ranks it
+// higher for the same token.
+const fuzzyScoreWeight = 8.0
+
+// fuzzyScore adds a small bonus for query tokens that don't match exactly
+// but are within edit distance of one of the atom's title/tag words.
+func (e *SearchEngine) fuzzyScore(atomID string, queryTokens []string, maxDistOverride int) float64 {
+	var score float64
+	for _, tok := range queryTokens {
+		if similarity := e.fullText.FuzzyMatch(atomID, tok, maxDistOverride); similarity > 0 {
+			score += similarity * fuzzyScoreWeight
+		}
 	}
+	return score
+}
 
-	// Add status and confidence priority for matched entries
-	matchScore += statusPriority[entry.Status] * 5
-	matchScore += confidencePriority[entry.Confidence] * 3
-
-	return matchScore
+// parseFuzziness interprets the fuzziness parameter: "off" disables fuzzy
+// matching (handled by the caller), "auto"/"" uses the length-scaled
+// default, and a numeric string fixes the edit-distance budget.
+func parseFuzziness(fuzziness string) int {
+	if fuzziness == "" || fuzziness == "auto" {
+		return -1
+	}
+	if n, err := strconv.Atoi(fuzziness); err == nil && n >= 0 {
+		return n
+	}
+	return -1
 }
 
 // formatResult formats a search result.
@@ -211,115 +377,32 @@ func (e *SearchEngine) formatResult(entry *models.IndexEntry, score int) SearchR
 	return result
 }
 
-// SearchContent performs deep search including atom content.
-func (e *SearchEngine) SearchContent(query []string, types []string, tags []string, language, status *string, limit int) ([]SearchResult, error) {
+// ExplainResult is the per-term BM25 breakdown returned by Explain.
+type ExplainResult struct {
+	AtomID string              `json:"atom_id"`
+	Query  string              `json:"query"`
+	Total  float64             `json:"total"`
+	Terms  []storage.TermScore `json:"terms"`
+}
+
+// Explain returns the per-term BM25 score breakdown for a single atom
+// against a query, for debugging ranking decisions.
+func (e *SearchEngine) Explain(id string, query string) (*ExplainResult, error) {
 	index, err := e.indexManager.GetIndex()
 	if err != nil {
 		return nil, err
 	}
-
-	type scoredEntry struct {
-		entry *models.IndexEntry
-		score int
-	}
-
-	var results []scoredEntry
-	queryTokens := normalizeTokens(query)
-
-	// Convert types to set for fast lookup
-	typeSet := make(map[models.AtomType]bool)
-	for _, t := range types {
-		typeSet[models.AtomType(t)] = true
-	}
-
-	for _, entry := range index.Atoms {
-		// Apply filters
-		if len(typeSet) > 0 && !typeSet[entry.Type] {
-			continue
-		}
-		if status != nil && string(entry.Status) != *status {
-			continue
-		}
-		if language != nil && (entry.Language == nil || *entry.Language != *language) {
-			continue
-		}
-		if len(tags) > 0 {
-			entryTagsLower := make(map[string]bool)
-			for _, t := range entry.Tags {
-				entryTagsLower[strings.ToLower(t)] = true
-			}
-			found := false
-			for _, tag := range tags {
-				if entryTagsLower[strings.ToLower(tag)] {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
-		}
-
-		// Calculate relevance score (including content)
-		score := e.calculateContentScore(entry, queryTokens)
-		if score > 0 {
-			results = append(results, scoredEntry{entry: entry, score: score})
-		}
-	}
-
-	// Sort by score (descending)
-	for i := 0; i < len(results); i++ {
-		for j := i + 1; j < len(results); j++ {
-			if results[j].score > results[i].score {
-				results[i], results[j] = results[j], results[i]
-			}
-		}
-	}
-
-	// Limit results
-	if len(results) > limit {
-		results = results[:limit]
-	}
-
-	// Format results
-	formatted := make([]SearchResult, 0, len(results))
-	for _, r := range results {
-		formatted = append(formatted, e.formatResult(r.entry, r.score))
-	}
-
-	return formatted, nil
-}
-
-// calculateContentScore calculates relevance score including content search.
-func (e *SearchEngine) calculateContentScore(entry *models.IndexEntry, queryTokens []string) int {
-	// Empty query returns all atoms with base score
-	if len(queryTokens) == 0 {
-		baseScore := 10
-		baseScore += statusPriority[entry.Status] * 5
-		baseScore += confidencePriority[entry.Confidence] * 3
-		return baseScore
+	if err := e.ensureFullTextIndex(index); err != nil {
+		return nil, err
 	}
 
-	// Start with basic score from title/tag matching
-	score := e.calculateScore(entry, queryTokens)
+	queryTokens := storage.Tokenize(query)
+	total, terms := e.fullText.Score(id, queryTokens)
 
-	// Also search in content
-	atom, err := e.atomStorage.Load(entry.ID)
-	if err == nil && atom != nil {
-		contentText := strings.ToLower(atom.Content.Summary + " " + atom.Content.Details)
-		for _, token := range queryTokens {
-			if strings.Contains(contentText, token) {
-				// If no title/tag match, give a base content match score
-				if score == 0 {
-					score = 20
-					score += statusPriority[entry.Status] * 5
-					score += confidencePriority[entry.Confidence] * 3
-				} else {
-					score += 20
-				}
-			}
-		}
-	}
-
-	return score
+	return &ExplainResult{
+		AtomID: id,
+		Query:  query,
+		Total:  total,
+		Terms:  terms,
+	}, nil
 }
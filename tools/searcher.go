@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sephriot/knowledge-mcp/config"
+	"github.com/sephriot/knowledge-mcp/models"
+	"github.com/sephriot/knowledge-mcp/storage"
+)
+
+// Searcher is implemented by every search backend - the in-process BM25
+// engine and the optional Elasticsearch/OpenSearch-backed one - so callers
+// (MCP tool handlers, upsert/atom tools) don't need to know which is active.
+type Searcher interface {
+	Search(query []string, types []string, tags []string, language, status *string, limit int, fuzziness string) ([]SearchResult, error)
+	SearchContent(query []string, types []string, tags []string, language, status *string, limit int, fuzziness string) ([]SearchResult, error)
+
+	// AddOrUpdate/Remove keep the search backend in sync as atoms change.
+	// Callers that already persist the atom and update the index call
+	// these afterwards; the local SearchEngine applies an incremental
+	// update to its full-text index, and the ES backend pushes the change
+	// to keep its remote documents current.
+	AddOrUpdate(atom *models.Atom) error
+	Remove(atomID string) error
+
+	// RebuildFromAtoms forces a full re-index from the atom store.
+	RebuildFromAtoms() error
+}
+
+// NewSearcher returns the configured Searcher implementation: the
+// Elasticsearch-backed one when config.ElasticsearchURL is set and
+// reachable, otherwise the local in-process BM25 engine.
+func NewSearcher(cfg *config.Config, indexManager *storage.IndexManager) Searcher {
+	if cfg == nil {
+		cfg = config.GetConfig()
+	}
+
+	local := NewSearchEngine(cfg, indexManager)
+
+	if cfg.ElasticsearchURL == "" {
+		return local
+	}
+
+	es, err := NewESSearchEngine(cfg, indexManager, local)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: elasticsearch backend unavailable (%v), falling back to local search\n", err)
+		return local
+	}
+	return es
+}
+
+// AttachToIndex does an initial RebuildFromAtoms so searcher starts current
+// with whatever is already in storage, then registers it as an
+// IndexManager.OnChange hook so every later atom write/delete is pushed to
+// it immediately - the ES backend's remote documents via single-document
+// index/delete requests (only its RebuildFromAtoms uses `_bulk`), the local
+// engine's persisted full-text postings via a cheap
+// incremental update - instead of leaving it to opportunistically notice
+// drift on the next Search. Mirrors graph.Graph.Attach's wiring.
+func AttachToIndex(searcher Searcher, indexManager *storage.IndexManager, atomStorage storage.AtomStorage) error {
+	if err := searcher.RebuildFromAtoms(); err != nil {
+		return err
+	}
+
+	indexManager.OnChange(func(atomID string) {
+		atom, err := atomStorage.Load(atomID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: search index failed to refresh atom %s: %v\n", atomID, err)
+			return
+		}
+
+		if atom == nil {
+			if err := searcher.Remove(atomID); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: search index failed to remove atom %s: %v\n", atomID, err)
+			}
+			return
+		}
+
+		if err := searcher.AddOrUpdate(atom); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: search index failed to update atom %s: %v\n", atomID, err)
+		}
+	})
+
+	return nil
+}
+
+// AddOrUpdate keeps the local engine's full-text index in sync with a
+// cheap incremental update, and stamps its Version with the atom index's
+// current UpdatedAt so ensureFullTextIndex doesn't consider it stale and
+// redo the work as a full RebuildFromAtoms on the next Search.
+func (e *SearchEngine) AddOrUpdate(atom *models.Atom) error {
+	if err := e.fullText.Load(); err != nil {
+		return err
+	}
+	e.fullText.AddOrUpdate(atom)
+	return e.syncVersionAndSave()
+}
+
+// Remove keeps the local engine's full-text index in sync, see AddOrUpdate.
+func (e *SearchEngine) Remove(atomID string) error {
+	if err := e.fullText.Load(); err != nil {
+		return err
+	}
+	e.fullText.Remove(atomID)
+	return e.syncVersionAndSave()
+}
+
+// syncVersionAndSave stamps the full-text index's Version with the atom
+// index's current UpdatedAt and persists it.
+func (e *SearchEngine) syncVersionAndSave() error {
+	index, err := e.indexManager.GetIndex()
+	if err != nil {
+		return err
+	}
+	e.fullText.Version = index.UpdatedAt
+	return e.fullText.Save()
+}
+
+// RebuildFromAtoms forces a full rebuild of the local full-text index.
+func (e *SearchEngine) RebuildFromAtoms() error {
+	index, err := e.indexManager.GetIndex()
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(index.Atoms))
+	for _, entry := range index.Atoms {
+		ids = append(ids, entry.ID)
+	}
+	return e.fullText.RebuildFromAtoms(e.atomStorage, ids, index.UpdatedAt)
+}
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/sephriot/knowledge-mcp/config"
@@ -13,7 +14,7 @@ import (
 type UpsertHandler struct {
 	config       *config.Config
 	indexManager *storage.IndexManager
-	atomStorage  *storage.AtomStorage
+	atomStorage  storage.AtomStorage
 }
 
 // NewUpsertHandler creates a new upsert handler.
@@ -30,58 +31,284 @@ func NewUpsertHandler(cfg *config.Config, indexManager *storage.IndexManager) *U
 
 // UpsertInput represents the input for an upsert operation.
 type UpsertInput struct {
-	ID         *string          `json:"id,omitempty"`
-	Title      string           `json:"title"`
-	Type       models.AtomType  `json:"type"`
+	ID         *string           `json:"id,omitempty"`
+	Title      string            `json:"title"`
+	Type       models.AtomType   `json:"type"`
 	Status     models.AtomStatus `json:"status"`
 	Confidence models.Confidence `json:"confidence"`
-	Summary    string           `json:"summary"`
-	Details    string           `json:"details"`
-	Pitfalls   []string         `json:"pitfalls"`
-	Language   *string          `json:"language,omitempty"`
-	Tags       []string         `json:"tags"`
-	Sources    []models.Source  `json:"sources"`
-	Links      []models.Link    `json:"links"`
+	Summary    string            `json:"summary"`
+	Details    string            `json:"details"`
+	Pitfalls   []string          `json:"pitfalls"`
+	Language   *string           `json:"language,omitempty"`
+	Tags       []string          `json:"tags"`
+	Sources    []models.Source   `json:"sources"`
+	Links      []models.Link     `json:"links"`
 }
 
 // Upsert creates or updates a knowledge atom.
 func (h *UpsertHandler) Upsert(input UpsertInput) (map[string]any, error) {
-	// Validate enum fields
+	atom, _, err := h.buildAndSaveAtom(input, time.Now().Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	entry := models.NewIndexEntryFromAtom(atom)
+	if err := h.indexManager.AddOrUpdate(entry); err != nil {
+		return nil, err
+	}
+
+	return atomToMap(atom), nil
+}
+
+// BulkUpsertItemResult is the per-item outcome of a BulkUpsert call,
+// modeled after Elasticsearch's `_bulk` response items.
+type BulkUpsertItemResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"` // "created", "updated", or "error"
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkUpsertResult is the aggregate response of a BulkUpsert call.
+type BulkUpsertResult struct {
+	Items   []BulkUpsertItemResult `json:"items"`
+	Created int                    `json:"created"`
+	Updated int                    `json:"updated"`
+	Errored int                    `json:"errored"`
+}
+
+// BulkUpsert creates or updates many atoms at once. Unlike Upsert, which
+// writes the whole index to disk after every call, BulkUpsert saves each
+// atom file individually but defers the index write until every atom has
+// been processed, persisting it exactly once via
+// IndexManager.BulkAddOrUpdate. A single input failing validation or I/O
+// does not abort the rest of the batch - its outcome is simply recorded as
+// an "error" item alongside the others' "created"/"updated" ones.
+func (h *UpsertHandler) BulkUpsert(inputs []UpsertInput) (BulkUpsertResult, error) {
+	today := time.Now().Format("2006-01-02")
+
+	result := BulkUpsertResult{Items: make([]BulkUpsertItemResult, 0, len(inputs))}
+	entries := make([]*models.IndexEntry, 0, len(inputs))
+
+	for _, input := range inputs {
+		atom, created, err := h.buildAndSaveAtom(input, today)
+		if err != nil {
+			id := ""
+			if input.ID != nil {
+				id = *input.ID
+			}
+			result.Items = append(result.Items, BulkUpsertItemResult{ID: id, Status: "error", Error: err.Error()})
+			result.Errored++
+			continue
+		}
+
+		status := "updated"
+		if created {
+			status = "created"
+			result.Created++
+		} else {
+			result.Updated++
+		}
+		result.Items = append(result.Items, BulkUpsertItemResult{ID: atom.ID, Status: status})
+		entries = append(entries, models.NewIndexEntryFromAtom(atom))
+	}
+
+	if len(entries) > 0 {
+		if err := h.indexManager.BulkAddOrUpdate(entries); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// UpsertManyResult is the aggregate response of an UpsertMany call.
+type UpsertManyResult struct {
+	Items      []BulkUpsertItemResult `json:"items"`
+	Created    int                    `json:"created"`
+	Updated    int                    `json:"updated"`
+	Errored    int                    `json:"errored"`
+	RolledBack bool                   `json:"rolled_back,omitempty"`
+}
+
+// UpsertMany is BulkUpsert with transactional, staged writes and an
+// on_error policy:
+//   - "abort" (fail fast): stops at the first invalid input and commits
+//     nothing - not even the inputs that validated before it.
+//   - "rollback": validates and stages every input first; if any of them
+//     failed, the whole batch is discarded and nothing is committed, but
+//     the response still reports every item's outcome.
+//   - "continue" (default): commits every input that validated, recording
+//     the rest as "error" items.
+//
+// New atoms are assigned a contiguous block of IDs up front (via a staged
+// copy of the index, so indexManager.GetNextID advances correctly without
+// touching the real index), and every atom file is written to a temporary
+// local staging directory (under config.LocalCacheDir, independent of
+// which AtomStorage backend h.config.DataPath selects) before anything is
+// moved into the real atom store - a crash or failure partway through
+// never corrupts index.yaml or leaves half-written atom files behind.
+func (h *UpsertHandler) UpsertMany(inputs []UpsertInput, onError string) (UpsertManyResult, error) {
+	if onError == "" {
+		onError = "continue"
+	}
+	if onError != "abort" && onError != "continue" && onError != "rollback" {
+		return UpsertManyResult{}, fmt.Errorf("invalid on_error mode: %s (want abort, continue, or rollback)", onError)
+	}
+
+	// Staged under LocalCacheDir rather than h.config.DataPath itself -
+	// DataPath may be a "gs://", "s3://", or "mem://" URL rather than a
+	// local directory, and MkdirTemp needs a real one regardless of which
+	// AtomStorage backend is configured.
+	if err := os.MkdirAll(h.config.LocalCacheDir(), 0755); err != nil {
+		return UpsertManyResult{}, fmt.Errorf("failed to create staging parent directory: %w", err)
+	}
+	stagingDir, err := os.MkdirTemp(h.config.LocalCacheDir(), "upsert-many-*")
+	if err != nil {
+		return UpsertManyResult{}, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stagingDir)
+	stagingStorage := storage.NewAtomStorage(&config.Config{DataPath: stagingDir, PreferredFormat: h.config.PreferredFormat})
+
+	// Preallocate a contiguous ID block for inputs without an explicit ID,
+	// using a staged copy of the index so each GetNextID call sees the
+	// previous allocation without persisting anything yet. Under
+	// IDSchemeContentHash this isn't needed - buildAtom mints each one
+	// directly from its content via assignID - so preallocation is skipped
+	// entirely.
+	preallocatedIDs := make([]string, len(inputs))
+	if h.config.IDScheme != config.IDSchemeContentHash {
+		index, err := h.indexManager.GetIndex()
+		if err != nil {
+			return UpsertManyResult{}, err
+		}
+		staged := index.Clone()
+		for i, input := range inputs {
+			if input.ID != nil && *input.ID != "" {
+				continue
+			}
+			newID := staged.GetNextID()
+			staged.AddOrUpdate(&models.IndexEntry{ID: newID})
+			preallocatedIDs[i] = newID
+		}
+	}
+
+	today := time.Now().Format("2006-01-02")
+	result := UpsertManyResult{Items: make([]BulkUpsertItemResult, 0, len(inputs))}
+	entries := make([]*models.IndexEntry, 0, len(inputs))
+	var stagedIDs []string
+
+	for i, input := range inputs {
+		if preallocatedIDs[i] != "" {
+			input.ID = &preallocatedIDs[i]
+		}
+
+		atom, created, err := h.buildAtom(input, today, stagingStorage)
+		if err != nil {
+			id := ""
+			if input.ID != nil {
+				id = *input.ID
+			}
+			result.Items = append(result.Items, BulkUpsertItemResult{ID: id, Status: "error", Error: err.Error()})
+			result.Errored++
+
+			if onError == "abort" {
+				return result, fmt.Errorf("upsert_many aborted at item %d (%s): %w", i, id, err)
+			}
+			continue
+		}
+
+		status := "updated"
+		if created {
+			status = "created"
+			result.Created++
+		} else {
+			result.Updated++
+		}
+		result.Items = append(result.Items, BulkUpsertItemResult{ID: atom.ID, Status: status})
+		entries = append(entries, models.NewIndexEntryFromAtom(atom))
+		stagedIDs = append(stagedIDs, atom.ID)
+	}
+
+	if onError == "rollback" && result.Errored > 0 {
+		result.Created = 0
+		result.Updated = 0
+		result.RolledBack = true
+		return result, nil
+	}
+
+	for _, atomID := range stagedIDs {
+		atom, err := stagingStorage.Load(atomID)
+		if err != nil || atom == nil {
+			return result, fmt.Errorf("failed to read staged atom %s: %w", atomID, err)
+		}
+		if _, err := h.atomStorage.Save(atom); err != nil {
+			return result, fmt.Errorf("failed to commit atom %s: %w", atomID, err)
+		}
+	}
+
+	if len(entries) > 0 {
+		if err := h.indexManager.BulkAddOrUpdate(entries); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// buildAndSaveAtom validates the input, builds the new or updated atom, and
+// saves its atom file via h.atomStorage. It does not touch the index -
+// callers are responsible for turning the returned atom into an IndexEntry
+// and persisting it, either one at a time (Upsert) or in a batch
+// (BulkUpsert). The returned bool is true if the atom was newly created.
+func (h *UpsertHandler) buildAndSaveAtom(input UpsertInput, today string) (*models.Atom, bool, error) {
+	return h.buildAtom(input, today, h.atomStorage)
+}
+
+// buildAtom is buildAndSaveAtom generalized over which AtomStorage the
+// built atom is saved to - UpsertMany saves to a staging AtomStorage so
+// nothing touches the real atom files until the whole batch is ready to
+// commit, while existing atoms are still read from h.atomStorage.
+func (h *UpsertHandler) buildAtom(input UpsertInput, today string, writeTo storage.AtomStorage) (*models.Atom, bool, error) {
 	if !input.Type.IsValid() {
-		return nil, fmt.Errorf("invalid atom type: %s", input.Type)
+		return nil, false, fmt.Errorf("invalid atom type: %s", input.Type)
 	}
 	if !input.Status.IsValid() {
-		return nil, fmt.Errorf("invalid atom status: %s", input.Status)
+		return nil, false, fmt.Errorf("invalid atom status: %s", input.Status)
 	}
 	if !input.Confidence.IsValid() {
-		return nil, fmt.Errorf("invalid confidence level: %s", input.Confidence)
+		return nil, false, fmt.Errorf("invalid confidence level: %s", input.Confidence)
 	}
 
-	today := time.Now().Format("2006-01-02")
-
-	// Handle existing atom update
 	if input.ID != nil && *input.ID != "" {
 		existing, err := h.atomStorage.Load(*input.ID)
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 		if existing != nil {
-			return h.updateAtom(existing, input, today)
+			atom, err := h.buildUpdatedAtom(existing, input, today)
+			if err != nil {
+				return nil, false, err
+			}
+			if _, err := writeTo.Save(atom); err != nil {
+				return nil, false, err
+			}
+			return atom, false, nil
 		}
 	}
 
-	// Create new atom
-	var id string
-	if input.ID != nil && *input.ID != "" {
-		id = *input.ID
-	} else {
-		var err error
-		id, err = h.indexManager.GetNextID()
-		if err != nil {
-			return nil, err
-		}
+	atom, err := h.buildNewAtom(input, today)
+	if err != nil {
+		return nil, false, err
+	}
+	if _, err := writeTo.Save(atom); err != nil {
+		return nil, false, err
 	}
+	return atom, true, nil
+}
 
+// buildNewAtom builds a brand-new atom from the input, assigning it an ID
+// if one wasn't supplied.
+func (h *UpsertHandler) buildNewAtom(input UpsertInput, today string) (*models.Atom, error) {
 	// Ensure slices are not nil
 	pitfalls := input.Pitfalls
 	if pitfalls == nil {
@@ -112,7 +339,6 @@ func (h *UpsertHandler) Upsert(input UpsertInput) (map[string]any, error) {
 
 	// Build atom
 	atom := &models.Atom{
-		ID:         id,
 		Title:      input.Title,
 		Type:       input.Type,
 		Status:     input.Status,
@@ -127,21 +353,38 @@ func (h *UpsertHandler) Upsert(input UpsertInput) (map[string]any, error) {
 		Supersedes: []string{},
 	}
 
-	// Save atom and update index
-	if _, err := h.atomStorage.Save(atom); err != nil {
+	id, err := h.assignID(input, atom)
+	if err != nil {
 		return nil, err
 	}
+	atom.ID = id
 
-	entry := models.NewIndexEntryFromAtom(atom)
-	if err := h.indexManager.AddOrUpdate(entry); err != nil {
-		return nil, err
+	return atom, nil
+}
+
+// assignID returns the ID a brand-new atom should be saved under: the
+// caller-supplied input.ID if present, otherwise one minted according to
+// h.config.IDScheme. atom must already have every hash-relevant field
+// (title, type, content, tags, sources, links) populated, since
+// IDSchemeContentHash hashes it directly.
+func (h *UpsertHandler) assignID(input UpsertInput, atom *models.Atom) (string, error) {
+	if input.ID != nil && *input.ID != "" {
+		return *input.ID, nil
 	}
 
-	return atomToMap(atom), nil
+	if h.config.IDScheme == config.IDSchemeContentHash {
+		return models.NewContentHashID(atom), nil
+	}
+
+	return h.indexManager.GetNextID()
 }
 
-// updateAtom updates an existing atom.
-func (h *UpsertHandler) updateAtom(existing *models.Atom, input UpsertInput, today string) (map[string]any, error) {
+// buildUpdatedAtom builds an updated atom from an existing one. Pitfalls,
+// tags, sources, and links left nil and details left empty in input keep
+// their existing values; created_at, supersedes, superseded_by, and
+// attachments are carried over. Every other field is taken from input as
+// given.
+func (h *UpsertHandler) buildUpdatedAtom(existing *models.Atom, input UpsertInput, today string) (*models.Atom, error) {
 	// Preserve existing update notes and add new one
 	updateNotes := append(existing.Content.UpdateNotes, models.UpdateNote{
 		Date: today,
@@ -197,17 +440,24 @@ func (h *UpsertHandler) updateAtom(existing *models.Atom, input UpsertInput, tod
 		SupersededBy: existing.SupersededBy,
 	}
 
-	// Save atom and update index
-	if _, err := h.atomStorage.Save(atom); err != nil {
-		return nil, err
-	}
+	// Attachments aren't part of UpsertInput, so keep the existing ones.
+	atom.Attachments = existing.Attachments
 
-	entry := models.NewIndexEntryFromAtom(atom)
-	if err := h.indexManager.AddOrUpdate(entry); err != nil {
-		return nil, err
+	// Under IDSchemeContentHash, existing.ID is a hash of the very fields
+	// this update just changed (title/type/summary/details/pitfalls/tags/
+	// sources/links - see models.ContentHash). Saving atom under the old ID
+	// would make it permanently fail verifyContentHash on the next Load.
+	// Reject the edit instead of silently writing an atom Load can never
+	// read back; callers that want to capture the new content should create
+	// a new atom (its content-hash ID will naturally dedupe against any
+	// existing atom with identical meaning-bearing fields).
+	if h.config.IDScheme == config.IDSchemeContentHash {
+		if newID := models.NewContentHashID(atom); newID != existing.ID {
+			return nil, fmt.Errorf("cannot update atom %s: its ID is a content hash and this edit changes hashed fields (title, type, summary, details, pitfalls, tags, sources, or links), which would invalidate the ID; create a new atom instead", existing.ID)
+		}
 	}
 
-	return atomToMap(atom), nil
+	return atom, nil
 }
 
 // atomToMap converts an atom to a map for JSON response.
@@ -224,12 +474,13 @@ func atomToMap(atom *models.Atom) map[string]any {
 			"pitfalls":     atom.Content.Pitfalls,
 			"update_notes": atom.Content.UpdateNotes,
 		},
-		"created_at": atom.CreatedAt,
-		"updated_at": atom.UpdatedAt,
-		"tags":       atom.Tags,
-		"sources":    atom.Sources,
-		"links":      atom.Links,
-		"supersedes": atom.Supersedes,
+		"created_at":  atom.CreatedAt,
+		"updated_at":  atom.UpdatedAt,
+		"tags":        atom.Tags,
+		"sources":     atom.Sources,
+		"links":       atom.Links,
+		"supersedes":  atom.Supersedes,
+		"attachments": atom.Attachments,
 	}
 
 	if atom.Language != nil {